@@ -0,0 +1,105 @@
+// Package async provides synchronization primitives whose operations
+// return promises instead of blocking: [Mutex], [RWMutex], [Semaphore],
+// [Barrier], [CountdownLatch] and [Phaser].
+//
+// An operation that would block in the sync package (such as locking
+// a locked mutex) returns a pending [azor.Promise] that resolves when
+// the operation completes. Waiters are served in FIFO order.
+// Each waiting operation takes a context: if the context is canceled
+// before the operation completes, the promise rejects with the
+// context's error, and the waiter leaves the queue.
+//
+// The zero values of the types are not usable.
+// Use the New functions to create them.
+package async
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	"github.com/nalgeon/azor"
+)
+
+// waiter is a pending operation.
+type waiter[T any] struct {
+	n       int64 // weight (for semaphores)
+	resolve func(T)
+	stop    func() bool   // stops watching the context
+	elem    *list.Element // nil when the waiter is not in the queue
+}
+
+// waitList is a FIFO queue of waiters.
+// All its methods must be called with the mutex held.
+type waitList[T any] struct {
+	mu *sync.Mutex
+	l  list.List
+}
+
+// add adds a waiter with the given weight to the queue and returns its
+// promise. If the context is canceled before the waiter is released,
+// removes it from the queue, rejects the promise and calls onCancel
+// (if not nil) with the mutex held.
+func (q *waitList[T]) add(ctx context.Context, n int64, onCancel func()) *azor.Promise[T] {
+	p, resolve, reject := azor.WithResolvers[T]()
+	if err := ctx.Err(); err != nil {
+		reject(context.Cause(ctx))
+		return p
+	}
+	w := &waiter[T]{n: n, resolve: resolve}
+	w.elem = q.l.PushBack(w)
+	w.stop = context.AfterFunc(ctx, func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if w.elem == nil {
+			// Already released.
+			return
+		}
+		q.l.Remove(w.elem)
+		w.elem = nil
+		reject(context.Cause(ctx))
+		if onCancel != nil {
+			onCancel()
+		}
+	})
+	return p
+}
+
+// front returns the first waiter in the queue,
+// or nil if the queue is empty.
+func (q *waitList[T]) front() *waiter[T] {
+	if e := q.l.Front(); e != nil {
+		return e.Value.(*waiter[T])
+	}
+	return nil
+}
+
+// release removes the waiter from the queue
+// and resolves its promise with the given value.
+func (q *waitList[T]) release(w *waiter[T], val T) {
+	q.l.Remove(w.elem)
+	w.elem = nil
+	w.stop()
+	w.resolve(val)
+}
+
+// releaseAll releases all the waiters in the queue
+// with the given value.
+func (q *waitList[T]) releaseAll(val T) {
+	for w := q.front(); w != nil; w = q.front() {
+		q.release(w, val)
+	}
+}
+
+// len returns the number of waiters in the queue.
+func (q *waitList[T]) len() int {
+	return q.l.Len()
+}
+
+// resolved returns a promise that is already
+// fulfilled with the given value.
+func resolved[T any](val T) *azor.Promise[T] {
+	p, resolve, _ := azor.WithResolvers[T]()
+	resolve(val)
+	return p
+}
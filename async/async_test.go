@@ -0,0 +1,26 @@
+package async
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nalgeon/azor"
+)
+
+// settled reports whether the promise settles within a short time.
+func settled[T any](p *azor.Promise[T]) bool {
+	select {
+	case <-p.Done():
+		return true
+	case <-time.After(10 * time.Millisecond):
+		return false
+	}
+}
+
+// canceled returns a context that is already canceled.
+func canceled(t *testing.T) context.Context {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	return ctx
+}
@@ -0,0 +1,242 @@
+package async
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nalgeon/azor"
+)
+
+// Barrier lets a fixed number of parties wait for each other.
+// When the last party arrives, all of them are released, and the
+// barrier resets for the next round (it's cyclic).
+type Barrier struct {
+	mu      sync.Mutex
+	parties int
+	waiters waitList[struct{}]
+}
+
+// NewBarrier creates a new barrier for the given number of parties.
+// Panics if the number of parties is not positive.
+func NewBarrier(parties int) *Barrier {
+	if parties <= 0 {
+		panic("async: number of parties must be positive")
+	}
+	b := &Barrier{parties: parties}
+	b.waiters.mu = &b.mu
+	return b
+}
+
+// Wait arrives at the barrier. Returns a promise that resolves
+// when all the parties have arrived, or rejects with the context's
+// error if the context is canceled first. A canceled party does not
+// count as arrived.
+func (b *Barrier) Wait(ctx context.Context) *azor.Promise[struct{}] {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.waiters.len()+1 >= b.parties && ctx.Err() == nil {
+		b.waiters.releaseAll(struct{}{})
+		return resolved(struct{}{})
+	}
+	return b.waiters.add(ctx, 1, nil)
+}
+
+// Waiting returns the number of parties waiting at the barrier.
+func (b *Barrier) Waiting() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.waiters.len()
+}
+
+// CountdownLatch lets parties wait until a count of events happens.
+// Once the count reaches zero, the latch stays open: all the current
+// and future waiters are released.
+type CountdownLatch struct {
+	mu      sync.Mutex
+	count   int
+	waiters waitList[struct{}]
+}
+
+// NewCountdownLatch creates a new latch with the given count.
+// Panics if the count is negative.
+func NewCountdownLatch(count int) *CountdownLatch {
+	if count < 0 {
+		panic("async: negative count")
+	}
+	l := &CountdownLatch{count: count}
+	l.waiters.mu = &l.mu
+	return l
+}
+
+// CountDown decrements the count, releasing all the waiters
+// if it reaches zero. Does nothing if the count is already zero.
+func (l *CountdownLatch) CountDown() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.count == 0 {
+		return
+	}
+	l.count--
+	if l.count == 0 {
+		l.waiters.releaseAll(struct{}{})
+	}
+}
+
+// Count returns the current count.
+func (l *CountdownLatch) Count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.count
+}
+
+// Wait returns a promise that resolves when the count reaches zero,
+// or rejects with the context's error if the context is canceled first.
+func (l *CountdownLatch) Wait(ctx context.Context) *azor.Promise[struct{}] {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.count == 0 {
+		return resolved(struct{}{})
+	}
+	return l.waiters.add(ctx, 1, nil)
+}
+
+// Phaser is a reusable barrier with a dynamic number of parties,
+// similar to Phaser in Java. Parties register with the phaser,
+// and the phaser advances to the next phase when all the registered
+// parties arrive. Phases are numbered from zero.
+type Phaser struct {
+	mu      sync.Mutex
+	phase   int
+	parties int // registered parties
+	arrived int // parties arrived in the current phase
+	waiters waitList[int]
+}
+
+// NewPhaser creates a new phaser with the given number
+// of registered parties. Panics if the number is negative.
+func NewPhaser(parties int) *Phaser {
+	if parties < 0 {
+		panic("async: negative number of parties")
+	}
+	p := &Phaser{parties: parties}
+	p.waiters.mu = &p.mu
+	return p
+}
+
+// Register adds a new party to the phaser.
+// Returns the current phase.
+func (p *Phaser) Register() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.parties++
+	return p.phase
+}
+
+// Arrive marks a party as arrived at the current phase without
+// waiting for the others. Returns the phase it arrived at.
+// Panics if all the registered parties have already arrived.
+func (p *Phaser) Arrive() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.arrive()
+}
+
+// ArriveAndDeregister marks a party as arrived and removes it
+// from the phaser, so the next phases don't wait for it.
+// Returns the phase it arrived at.
+// Panics if there are no registered parties.
+func (p *Phaser) ArriveAndDeregister() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.parties == 0 {
+		panic("async: no registered parties")
+	}
+	phase := p.phase
+	p.parties--
+	if p.arrived >= p.parties {
+		p.advance()
+	}
+	return phase
+}
+
+// ArriveAndAwait marks a party as arrived and returns a promise
+// that resolves with the next phase number when all the parties
+// have arrived. If the context is canceled first, the promise
+// rejects with the context's error, but the arrival still counts.
+func (p *Phaser) ArriveAndAwait(ctx context.Context) *azor.Promise[int] {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	phase := p.arrive()
+	return p.await(ctx, phase)
+}
+
+// AwaitAdvance returns a promise that resolves with the next
+// phase number when the phaser advances from the given phase.
+// If the phaser is already past the phase, the promise resolves
+// right away with the current phase. If the context is canceled
+// first, the promise rejects with the context's error.
+func (p *Phaser) AwaitAdvance(ctx context.Context, phase int) *azor.Promise[int] {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.await(ctx, phase)
+}
+
+// Phase returns the current phase number.
+func (p *Phaser) Phase() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.phase
+}
+
+// Parties returns the number of registered parties.
+func (p *Phaser) Parties() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.parties
+}
+
+// arrive marks a party as arrived and advances the phase
+// if it was the last one. Returns the phase it arrived at.
+// Must be called with the mutex held.
+func (p *Phaser) arrive() int {
+	if p.arrived >= p.parties {
+		panic("async: more arrivals than registered parties")
+	}
+	phase := p.phase
+	p.arrived++
+	if p.arrived == p.parties {
+		p.advance()
+	}
+	return phase
+}
+
+// await returns a promise that resolves when
+// the phaser advances from the given phase.
+// Must be called with the mutex held.
+func (p *Phaser) await(ctx context.Context, phase int) *azor.Promise[int] {
+	if p.phase != phase {
+		return resolved(p.phase)
+	}
+	return p.waiters.add(ctx, 1, nil)
+}
+
+// advance moves the phaser to the next phase
+// and releases the waiters.
+// Must be called with the mutex held.
+func (p *Phaser) advance() {
+	p.phase++
+	p.arrived = 0
+	p.waiters.releaseAll(p.phase)
+}
@@ -0,0 +1,150 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nalgeon/azor"
+)
+
+func TestBarrier(t *testing.T) {
+	t.Run("release", func(t *testing.T) {
+		b := NewBarrier(3)
+		p1, p2 := b.Wait(t.Context()), b.Wait(t.Context())
+		if settled(p1) || settled(p2) {
+			t.Error("parties should wait")
+		}
+		if b.Waiting() != 2 {
+			t.Errorf("got %d waiting, want 2", b.Waiting())
+		}
+		p3 := b.Wait(t.Context())
+		if !settled(p1) || !settled(p2) || !settled(p3) {
+			t.Error("all parties should be released")
+		}
+	})
+	t.Run("cyclic", func(t *testing.T) {
+		b := NewBarrier(2)
+		b.Wait(t.Context())
+		b.Wait(t.Context())
+		p := b.Wait(t.Context())
+		if settled(p) {
+			t.Error("next round should wait")
+		}
+		b.Wait(t.Context())
+		if !settled(p) {
+			t.Error("next round should be released")
+		}
+	})
+	t.Run("cancel", func(t *testing.T) {
+		b := NewBarrier(2)
+		ctx, cancel := context.WithCancel(t.Context())
+		p1 := b.Wait(ctx)
+		cancel()
+		if _, err := p1.Get(t.Context()); !errors.Is(err, context.Canceled) {
+			t.Errorf("got err = %v, want %v", err, context.Canceled)
+		}
+		// The canceled party does not count as arrived.
+		p2 := b.Wait(t.Context())
+		if settled(p2) {
+			t.Error("party should wait")
+		}
+		b.Wait(t.Context())
+		if !settled(p2) {
+			t.Error("party should be released")
+		}
+	})
+}
+
+func TestCountdownLatch(t *testing.T) {
+	t.Run("count down", func(t *testing.T) {
+		l := NewCountdownLatch(2)
+		p := l.Wait(t.Context())
+		l.CountDown()
+		if settled(p) {
+			t.Error("should wait for count")
+		}
+		l.CountDown()
+		if !settled(p) {
+			t.Error("should be released")
+		}
+		if l.Count() != 0 {
+			t.Errorf("got count %d, want 0", l.Count())
+		}
+		l.CountDown()
+		if !settled(l.Wait(t.Context())) {
+			t.Error("open latch should not wait")
+		}
+	})
+	t.Run("cancel", func(t *testing.T) {
+		l := NewCountdownLatch(1)
+		if _, err := l.Wait(canceled(t)).Get(t.Context()); !errors.Is(err, context.Canceled) {
+			t.Errorf("got err = %v, want %v", err, context.Canceled)
+		}
+	})
+}
+
+func TestPhaser(t *testing.T) {
+	t.Run("advance", func(t *testing.T) {
+		ph := NewPhaser(2)
+		p1 := ph.ArriveAndAwait(t.Context())
+		if settled(p1) {
+			t.Error("should wait for other party")
+		}
+		p2 := ph.ArriveAndAwait(t.Context())
+		for _, p := range []*azor.Promise[int]{p1, p2} {
+			if phase, err := p.Get(t.Context()); err != nil || phase != 1 {
+				t.Errorf("got %v, %v; want 1, nil", phase, err)
+			}
+		}
+		if ph.Phase() != 1 {
+			t.Errorf("got phase %d, want 1", ph.Phase())
+		}
+	})
+	t.Run("register", func(t *testing.T) {
+		ph := NewPhaser(1)
+		if phase := ph.Register(); phase != 0 {
+			t.Errorf("got phase %d, want 0", phase)
+		}
+		if ph.Parties() != 2 {
+			t.Errorf("got %d parties, want 2", ph.Parties())
+		}
+		ph.Arrive()
+		if ph.Phase() != 0 {
+			t.Error("should not advance before all arrive")
+		}
+		ph.Arrive()
+		if ph.Phase() != 1 {
+			t.Error("should advance after all arrive")
+		}
+	})
+	t.Run("deregister", func(t *testing.T) {
+		ph := NewPhaser(3)
+		p := ph.ArriveAndAwait(t.Context())
+		ph.Arrive()
+		if settled(p) {
+			t.Error("should wait for last party")
+		}
+		ph.ArriveAndDeregister()
+		if !settled(p) {
+			t.Error("should advance when last party deregisters")
+		}
+		if ph.Parties() != 2 {
+			t.Errorf("got %d parties, want 2", ph.Parties())
+		}
+	})
+	t.Run("await past phase", func(t *testing.T) {
+		ph := NewPhaser(1)
+		ph.Arrive()
+		if phase, _ := ph.AwaitAdvance(t.Context(), 0).Get(t.Context()); phase != 1 {
+			t.Errorf("got phase %d, want 1", phase)
+		}
+	})
+	t.Run("cancel", func(t *testing.T) {
+		ph := NewPhaser(2)
+		p := ph.ArriveAndAwait(canceled(t))
+		if _, err := p.Get(t.Context()); !errors.Is(err, context.Canceled) {
+			t.Errorf("got err = %v, want %v", err, context.Canceled)
+		}
+	})
+}
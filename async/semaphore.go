@@ -0,0 +1,159 @@
+package async
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nalgeon/azor"
+)
+
+// Semaphore is a weighted semaphore: it limits the total weight
+// of the holders to its size. Waiters are served in FIFO order,
+// so a large request is not starved by smaller ones that arrive
+// after it.
+type Semaphore struct {
+	mu      sync.Mutex
+	size    int64
+	cur     int64 // total weight of the holders
+	waiters waitList[struct{}]
+}
+
+// NewSemaphore creates a new semaphore with the given size.
+// Panics if the size is not positive.
+func NewSemaphore(size int64) *Semaphore {
+	if size <= 0 {
+		panic("async: semaphore size must be positive")
+	}
+	s := &Semaphore{size: size}
+	s.waiters.mu = &s.mu
+	return s
+}
+
+// Acquire acquires the semaphore with a weight of n. Returns
+// a promise that resolves when the weight is acquired, or rejects
+// with the context's error if the context is canceled first.
+// Panics if n is not positive or exceeds the semaphore size.
+func (s *Semaphore) Acquire(ctx context.Context, n int64) *azor.Promise[struct{}] {
+	if n <= 0 || n > s.size {
+		panic("async: invalid semaphore weight")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cur+n <= s.size && s.waiters.len() == 0 {
+		s.cur += n
+		return resolved(struct{}{})
+	}
+	return s.waiters.add(ctx, n, s.notify)
+}
+
+// TryAcquire acquires the semaphore with a weight of n
+// without waiting. Reports whether it succeeded.
+func (s *Semaphore) TryAcquire(n int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cur+n <= s.size && s.waiters.len() == 0 {
+		s.cur += n
+		return true
+	}
+	return false
+}
+
+// Release releases the semaphore with a weight of n
+// and lets the waiters through if they fit.
+// Panics if releasing more than is held.
+func (s *Semaphore) Release(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cur -= n
+	if s.cur < 0 {
+		panic("async: released more than held")
+	}
+	s.notify()
+}
+
+// notify lets the waiters through in FIFO order,
+// while they fit. Must be called with the mutex held.
+func (s *Semaphore) notify() {
+	for w := s.waiters.front(); w != nil; w = s.waiters.front() {
+		if s.cur+w.n > s.size {
+			break
+		}
+		s.cur += w.n
+		s.waiters.release(w, struct{}{})
+	}
+}
+
+// Mutex is a mutual exclusion lock.
+type Mutex struct {
+	sem *Semaphore
+}
+
+// NewMutex creates a new unlocked mutex.
+func NewMutex() *Mutex {
+	return &Mutex{sem: NewSemaphore(1)}
+}
+
+// Lock locks the mutex. Returns a promise that resolves when the
+// mutex is locked, or rejects with the context's error if the
+// context is canceled first.
+func (m *Mutex) Lock(ctx context.Context) *azor.Promise[struct{}] {
+	return m.sem.Acquire(ctx, 1)
+}
+
+// TryLock locks the mutex without waiting.
+// Reports whether it succeeded.
+func (m *Mutex) TryLock() bool {
+	return m.sem.TryAcquire(1)
+}
+
+// Unlock unlocks the mutex and lets the next waiter (if any) lock it.
+// Panics if the mutex is not locked.
+func (m *Mutex) Unlock() {
+	m.sem.Release(1)
+}
+
+// maxReaders is the maximum number of readers
+// holding an RWMutex at the same time.
+const maxReaders = 1 << 30
+
+// RWMutex is a reader/writer mutual exclusion lock.
+// The lock can be held by any number of readers or by a single writer.
+// Waiters are served in FIFO order, so a waiting writer blocks the
+// readers that arrive after it.
+type RWMutex struct {
+	sem *Semaphore
+}
+
+// NewRWMutex creates a new unlocked reader/writer mutex.
+func NewRWMutex() *RWMutex {
+	return &RWMutex{sem: NewSemaphore(maxReaders)}
+}
+
+// Lock locks the mutex for writing. Returns a promise that resolves
+// when the mutex is locked, or rejects with the context's error
+// if the context is canceled first.
+func (rw *RWMutex) Lock(ctx context.Context) *azor.Promise[struct{}] {
+	return rw.sem.Acquire(ctx, maxReaders)
+}
+
+// Unlock unlocks the mutex for writing.
+// Panics if the mutex is not locked for writing.
+func (rw *RWMutex) Unlock() {
+	rw.sem.Release(maxReaders)
+}
+
+// RLock locks the mutex for reading. Returns a promise that resolves
+// when the mutex is locked, or rejects with the context's error
+// if the context is canceled first.
+func (rw *RWMutex) RLock(ctx context.Context) *azor.Promise[struct{}] {
+	return rw.sem.Acquire(ctx, 1)
+}
+
+// RUnlock undoes a single RLock call.
+// Panics if the mutex is not locked for reading.
+func (rw *RWMutex) RUnlock() {
+	rw.sem.Release(1)
+}
@@ -0,0 +1,196 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestSemaphore(t *testing.T) {
+	t.Run("acquire", func(t *testing.T) {
+		s := NewSemaphore(3)
+		if !settled(s.Acquire(t.Context(), 2)) {
+			t.Error("first acquire should succeed")
+		}
+		p := s.Acquire(t.Context(), 2)
+		if settled(p) {
+			t.Error("second acquire should wait")
+		}
+		s.Release(2)
+		if !settled(p) {
+			t.Error("second acquire should succeed after release")
+		}
+	})
+	t.Run("fifo", func(t *testing.T) {
+		s := NewSemaphore(2)
+		s.Acquire(t.Context(), 2)
+		var mu sync.Mutex
+		var order []int
+		var ps []<-chan struct{}
+		for i, n := range []int64{2, 1, 1} {
+			p := s.Acquire(t.Context(), n)
+			done := make(chan struct{})
+			go func() {
+				_, _ = p.Get(t.Context())
+				mu.Lock()
+				order = append(order, i)
+				mu.Unlock()
+				close(done)
+			}()
+			ps = append(ps, done)
+		}
+		s.Release(2) // lets the first waiter through
+		<-ps[0]
+		s.Release(2) // lets both small waiters through
+		<-ps[1]
+		<-ps[2]
+		if order[0] != 0 {
+			t.Errorf("got order %v, want 0 first", order)
+		}
+	})
+	t.Run("large waiter blocks smaller", func(t *testing.T) {
+		s := NewSemaphore(2)
+		s.Acquire(t.Context(), 1)
+		big := s.Acquire(t.Context(), 2)
+		small := s.Acquire(t.Context(), 1)
+		if settled(small) {
+			t.Error("small waiter should wait behind big one")
+		}
+		s.Release(1)
+		if !settled(big) {
+			t.Error("big waiter should succeed")
+		}
+		if settled(small) {
+			t.Error("small waiter should still wait")
+		}
+		s.Release(2)
+		if !settled(small) {
+			t.Error("small waiter should succeed")
+		}
+	})
+	t.Run("cancel", func(t *testing.T) {
+		s := NewSemaphore(2)
+		s.Acquire(t.Context(), 1)
+		ctx, cancel := context.WithCancel(t.Context())
+		big := s.Acquire(ctx, 2)
+		small := s.Acquire(t.Context(), 1)
+		cancel()
+		if _, err := big.Get(t.Context()); !errors.Is(err, context.Canceled) {
+			t.Errorf("got err = %v, want %v", err, context.Canceled)
+		}
+		// The canceled waiter no longer blocks the next one.
+		if !settled(small) {
+			t.Error("small waiter should succeed")
+		}
+	})
+	t.Run("canceled context", func(t *testing.T) {
+		s := NewSemaphore(1)
+		s.Acquire(t.Context(), 1)
+		p := s.Acquire(canceled(t), 1)
+		if _, err := p.Get(t.Context()); !errors.Is(err, context.Canceled) {
+			t.Errorf("got err = %v, want %v", err, context.Canceled)
+		}
+	})
+	t.Run("try acquire", func(t *testing.T) {
+		s := NewSemaphore(1)
+		if !s.TryAcquire(1) {
+			t.Error("first try should succeed")
+		}
+		if s.TryAcquire(1) {
+			t.Error("second try should fail")
+		}
+	})
+	t.Run("release too much", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("want panic")
+			}
+		}()
+		NewSemaphore(1).Release(1)
+	})
+}
+
+func TestMutex(t *testing.T) {
+	t.Run("exclusive", func(t *testing.T) {
+		m := NewMutex()
+		counter := 0
+		var wg sync.WaitGroup
+		for range 50 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = m.Lock(t.Context()).Get(t.Context())
+				counter++
+				m.Unlock()
+			}()
+		}
+		wg.Wait()
+		if counter != 50 {
+			t.Errorf("got %d, want 50", counter)
+		}
+	})
+	t.Run("fifo", func(t *testing.T) {
+		m := NewMutex()
+		m.Lock(t.Context())
+		var order []int
+		var ps []func()
+		for i := range 5 {
+			p := m.Lock(t.Context())
+			ps = append(ps, func() {
+				_, _ = p.Get(t.Context())
+				order = append(order, i)
+				m.Unlock()
+			})
+		}
+		m.Unlock()
+		for _, wait := range ps {
+			wait()
+		}
+		if !slices.Equal(order, []int{0, 1, 2, 3, 4}) {
+			t.Errorf("got %v, want [0 1 2 3 4]", order)
+		}
+	})
+	t.Run("try lock", func(t *testing.T) {
+		m := NewMutex()
+		if !m.TryLock() || m.TryLock() {
+			t.Error("want first TryLock to succeed and second to fail")
+		}
+	})
+}
+
+func TestRWMutex(t *testing.T) {
+	t.Run("readers", func(t *testing.T) {
+		rw := NewRWMutex()
+		if !settled(rw.RLock(t.Context())) || !settled(rw.RLock(t.Context())) {
+			t.Error("readers should share the lock")
+		}
+		w := rw.Lock(t.Context())
+		if settled(w) {
+			t.Error("writer should wait for readers")
+		}
+		rw.RUnlock()
+		rw.RUnlock()
+		if !settled(w) {
+			t.Error("writer should lock after readers")
+		}
+	})
+	t.Run("writer blocks later readers", func(t *testing.T) {
+		rw := NewRWMutex()
+		rw.RLock(t.Context())
+		w := rw.Lock(t.Context())
+		r := rw.RLock(t.Context())
+		if settled(r) {
+			t.Error("reader should wait behind writer")
+		}
+		rw.RUnlock()
+		if !settled(w) {
+			t.Error("writer should lock")
+		}
+		rw.Unlock()
+		if !settled(r) {
+			t.Error("reader should lock after writer")
+		}
+	})
+}
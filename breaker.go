@@ -0,0 +1,271 @@
+package azor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is the error that a [CircuitBreaker]
+// rejects calls with while it's open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState is the state of a [CircuitBreaker].
+type BreakerState int
+
+const (
+	// BreakerClosed means calls go through,
+	// and the breaker tracks their failure rate.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen means calls fail fast with [ErrCircuitOpen].
+	BreakerOpen
+	// BreakerHalfOpen means a limited number of trial calls
+	// go through to check if the dependency has recovered.
+	BreakerHalfOpen
+)
+
+// String returns the name of the state.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerConfig configures a [CircuitBreaker].
+// Zero fields are replaced with the defaults.
+type BreakerConfig struct {
+	// Window is the period over which the failure rate
+	// is calculated. Default is 10 seconds.
+	Window time.Duration
+
+	// FailureRate is the fraction of failed calls (0 to 1)
+	// in the window that opens the breaker. Default is 0.5.
+	FailureRate float64
+
+	// MinCalls is the minimum number of calls in the window
+	// before the breaker can open. Default is 10.
+	MinCalls int
+
+	// Cooldown is how long the breaker stays open
+	// before allowing trial calls. Default is 5 seconds.
+	Cooldown time.Duration
+
+	// HalfOpenCalls is the number of trial calls allowed in the
+	// half-open state. If all of them succeed, the breaker closes.
+	// If any of them fails, the breaker opens again. Default is 1.
+	HalfOpenCalls int
+
+	// OnStateChange is called after the breaker changes its state.
+	// Optional.
+	OnStateChange func(from, to BreakerState)
+}
+
+// withDefaults returns the config with
+// zero fields replaced by the defaults.
+func (c BreakerConfig) withDefaults() BreakerConfig {
+	if c.Window <= 0 {
+		c.Window = 10 * time.Second
+	}
+	if c.FailureRate <= 0 {
+		c.FailureRate = 0.5
+	}
+	if c.MinCalls <= 0 {
+		c.MinCalls = 10
+	}
+	if c.Cooldown <= 0 {
+		c.Cooldown = 5 * time.Second
+	}
+	if c.HalfOpenCalls <= 0 {
+		c.HalfOpenCalls = 1
+	}
+	return c
+}
+
+// CircuitBreaker wraps an asynchronous function and stops calling it
+// when it fails too often, so that callers fail fast instead of piling up
+// goroutines waiting on a broken dependency.
+//
+// The breaker starts closed. When the failure rate over the window
+// reaches the threshold, the breaker opens and rejects calls with
+// [ErrCircuitOpen]. After the cooldown, it becomes half-open and lets
+// a few trial calls through. If they succeed, the breaker closes;
+// otherwise, it opens again.
+//
+// CircuitBreaker is safe for concurrent use.
+type CircuitBreaker[T any] struct {
+	fn  AsyncFunc[T]
+	cfg BreakerConfig
+
+	mu       sync.Mutex
+	state    BreakerState
+	gen      int       // incremented on each state change
+	openedAt time.Time // when the breaker opened
+	calls    []call    // calls in the window, oldest first
+	trials   int       // trial calls started in the half-open state
+	passed   int       // trial calls succeeded in the half-open state
+}
+
+// call is the outcome of a call made through the breaker.
+type call struct {
+	at     time.Time
+	failed bool
+}
+
+// NewCircuitBreaker creates a new closed circuit breaker
+// that wraps the given function.
+// Panics if the function is nil.
+func NewCircuitBreaker[T any](fn AsyncFunc[T], cfg BreakerConfig) *CircuitBreaker[T] {
+	if fn == nil {
+		panic("azor: nil function")
+	}
+	return &CircuitBreaker[T]{fn: fn, cfg: cfg.withDefaults()}
+}
+
+// Call calls the wrapped function if the breaker allows it,
+// and returns the function's promise. Otherwise, returns a promise
+// rejected with [ErrCircuitOpen].
+func (cb *CircuitBreaker[T]) Call() *Promise[T] {
+	gen, ok := cb.allow()
+	if !ok {
+		return rejected[T](ErrCircuitOpen)
+	}
+	p := cb.fn()
+	go func() {
+		_, err := p.Get(context.Background())
+		cb.record(gen, err)
+	}()
+	return p
+}
+
+// Func returns an asynchronous function that calls
+// the wrapped function through the breaker.
+func (cb *CircuitBreaker[T]) Func() AsyncFunc[T] {
+	return cb.Call
+}
+
+// State returns the current state of the breaker.
+func (cb *CircuitBreaker[T]) State() BreakerState {
+	cb.mu.Lock()
+	notify := cb.cool(time.Now())
+	state := cb.state
+	cb.mu.Unlock()
+	notify()
+	return state
+}
+
+// allow reports whether the breaker lets a call through.
+// Returns the state generation the call belongs to.
+func (cb *CircuitBreaker[T]) allow() (gen int, ok bool) {
+	cb.mu.Lock()
+	notify := cb.cool(time.Now())
+	switch cb.state {
+	case BreakerClosed:
+		ok = true
+	case BreakerHalfOpen:
+		if cb.trials < cb.cfg.HalfOpenCalls {
+			cb.trials++
+			ok = true
+		}
+	}
+	gen = cb.gen
+	cb.mu.Unlock()
+	notify()
+	return gen, ok
+}
+
+// record registers the outcome of a call and changes
+// the state of the breaker if necessary. Ignores calls
+// that started before the last state change.
+func (cb *CircuitBreaker[T]) record(gen int, err error) {
+	cb.mu.Lock()
+	if gen != cb.gen {
+		cb.mu.Unlock()
+		return
+	}
+
+	now := time.Now()
+	notify := func() {}
+	switch cb.state {
+	case BreakerClosed:
+		cb.calls = append(cb.calls, call{at: now, failed: err != nil})
+		cb.prune(now)
+		if cb.tripped() {
+			notify = cb.transition(BreakerOpen, now)
+		}
+	case BreakerHalfOpen:
+		if err != nil {
+			notify = cb.transition(BreakerOpen, now)
+			break
+		}
+		cb.passed++
+		if cb.passed >= cb.cfg.HalfOpenCalls {
+			notify = cb.transition(BreakerClosed, now)
+		}
+	}
+	cb.mu.Unlock()
+	notify()
+}
+
+// cool moves an open breaker to the half-open state
+// if the cooldown has passed. Returns a function that
+// notifies about the state change (if any).
+func (cb *CircuitBreaker[T]) cool(now time.Time) func() {
+	if cb.state == BreakerOpen && now.Sub(cb.openedAt) >= cb.cfg.Cooldown {
+		return cb.transition(BreakerHalfOpen, now)
+	}
+	return func() {}
+}
+
+// prune removes the calls that are outside the window.
+func (cb *CircuitBreaker[T]) prune(now time.Time) {
+	start := now.Add(-cb.cfg.Window)
+	i := 0
+	for i < len(cb.calls) && cb.calls[i].at.Before(start) {
+		i++
+	}
+	cb.calls = cb.calls[i:]
+}
+
+// tripped reports whether the failure rate
+// in the window has reached the threshold.
+func (cb *CircuitBreaker[T]) tripped() bool {
+	if len(cb.calls) < cb.cfg.MinCalls {
+		return false
+	}
+	failed := 0
+	for _, c := range cb.calls {
+		if c.failed {
+			failed++
+		}
+	}
+	return float64(failed)/float64(len(cb.calls)) >= cb.cfg.FailureRate
+}
+
+// transition changes the state of the breaker and resets
+// the state-specific counters. Returns a function that calls
+// the OnStateChange callback, which should be called
+// after releasing the lock.
+func (cb *CircuitBreaker[T]) transition(to BreakerState, now time.Time) func() {
+	from := cb.state
+	cb.state = to
+	cb.gen++
+	cb.calls = nil
+	cb.trials = 0
+	cb.passed = 0
+	if to == BreakerOpen {
+		cb.openedAt = now
+	}
+	return func() {
+		if cb.cfg.OnStateChange != nil {
+			cb.cfg.OnStateChange(from, to)
+		}
+	}
+}
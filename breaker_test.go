@@ -0,0 +1,253 @@
+package azor
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flaky returns an asynchronous function that fails
+// while the fail flag is set, and counts the calls.
+func flaky(fail *atomic.Bool, calls *atomic.Int32) AsyncFunc[int] {
+	return Async(func() (int, error) {
+		calls.Add(1)
+		if fail.Load() {
+			return 0, errDummy
+		}
+		return 42, nil
+	})
+}
+
+// waitState waits until the breaker reaches the given state.
+func waitState[T any](t *testing.T, cb *CircuitBreaker[T], want BreakerState) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for cb.State() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("got state %v, want %v", cb.State(), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Run("closed", func(t *testing.T) {
+		var fail atomic.Bool
+		var calls atomic.Int32
+		cb := NewCircuitBreaker(flaky(&fail, &calls), BreakerConfig{MinCalls: 2})
+
+		for range 5 {
+			val, err := cb.Call().Get(t.Context())
+			if err != nil {
+				t.Fatalf("got err = %v, want nil", err)
+			}
+			if val != 42 {
+				t.Fatalf("got val = %d, want 42", val)
+			}
+		}
+		if cb.State() != BreakerClosed {
+			t.Errorf("got state %v, want closed", cb.State())
+		}
+	})
+	t.Run("open", func(t *testing.T) {
+		var fail atomic.Bool
+		var calls atomic.Int32
+		fail.Store(true)
+		cb := NewCircuitBreaker(flaky(&fail, &calls), BreakerConfig{
+			MinCalls: 3,
+			Cooldown: time.Minute,
+		})
+
+		for range 3 {
+			_, err := cb.Call().Get(t.Context())
+			if !errors.Is(err, errDummy) {
+				t.Fatalf("got err = %v, want %v", err, errDummy)
+			}
+		}
+		waitState(t, cb, BreakerOpen)
+
+		_, err := cb.Call().Get(t.Context())
+		if !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("got err = %v, want %v", err, ErrCircuitOpen)
+		}
+		if calls.Load() != 3 {
+			t.Errorf("got %d calls, want 3", calls.Load())
+		}
+	})
+	t.Run("failure rate", func(t *testing.T) {
+		var fail atomic.Bool
+		var calls atomic.Int32
+		cb := NewCircuitBreaker(flaky(&fail, &calls), BreakerConfig{
+			MinCalls:    4,
+			FailureRate: 0.5,
+			Cooldown:    time.Minute,
+		})
+
+		// 1 failure out of 4 calls: below the threshold.
+		for i := range 4 {
+			fail.Store(i == 0)
+			_, _ = cb.Call().Get(t.Context())
+		}
+		time.Sleep(10 * time.Millisecond)
+		if cb.State() != BreakerClosed {
+			t.Fatalf("got state %v, want closed", cb.State())
+		}
+
+		// 3 failures out of 6 calls: reaches the threshold.
+		fail.Store(true)
+		for range 2 {
+			_, _ = cb.Call().Get(t.Context())
+		}
+		waitState(t, cb, BreakerOpen)
+	})
+	t.Run("window", func(t *testing.T) {
+		var fail atomic.Bool
+		var calls atomic.Int32
+		fail.Store(true)
+		cb := NewCircuitBreaker(flaky(&fail, &calls), BreakerConfig{
+			Window:   20 * time.Millisecond,
+			MinCalls: 2,
+		})
+
+		// The first failure falls out of the window
+		// before the second one happens.
+		_, _ = cb.Call().Get(t.Context())
+		time.Sleep(40 * time.Millisecond)
+		_, _ = cb.Call().Get(t.Context())
+		time.Sleep(10 * time.Millisecond)
+		if cb.State() != BreakerClosed {
+			t.Errorf("got state %v, want closed", cb.State())
+		}
+	})
+	t.Run("half-open success", func(t *testing.T) {
+		var fail atomic.Bool
+		var calls atomic.Int32
+		fail.Store(true)
+		cb := NewCircuitBreaker(flaky(&fail, &calls), BreakerConfig{
+			MinCalls: 1,
+			Cooldown: 20 * time.Millisecond,
+		})
+
+		_, _ = cb.Call().Get(t.Context())
+		waitState(t, cb, BreakerOpen)
+		waitState(t, cb, BreakerHalfOpen)
+
+		fail.Store(false)
+		val, err := cb.Call().Get(t.Context())
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if val != 42 {
+			t.Errorf("got val = %d, want 42", val)
+		}
+		waitState(t, cb, BreakerClosed)
+	})
+	t.Run("half-open failure", func(t *testing.T) {
+		var fail atomic.Bool
+		var calls atomic.Int32
+		fail.Store(true)
+		cb := NewCircuitBreaker(flaky(&fail, &calls), BreakerConfig{
+			MinCalls: 1,
+			Cooldown: 20 * time.Millisecond,
+		})
+
+		_, _ = cb.Call().Get(t.Context())
+		waitState(t, cb, BreakerHalfOpen)
+
+		_, err := cb.Call().Get(t.Context())
+		if !errors.Is(err, errDummy) {
+			t.Errorf("got err = %v, want %v", err, errDummy)
+		}
+		waitState(t, cb, BreakerOpen)
+	})
+	t.Run("half-open limit", func(t *testing.T) {
+		release := make(chan struct{})
+		var fail atomic.Bool
+		fail.Store(true)
+		fn := Async(func() (int, error) {
+			if fail.Load() {
+				return 0, errDummy
+			}
+			<-release
+			return 42, nil
+		})
+		cb := NewCircuitBreaker(fn, BreakerConfig{
+			MinCalls: 1,
+			Cooldown: 10 * time.Millisecond,
+		})
+
+		_, _ = cb.Call().Get(t.Context())
+		waitState(t, cb, BreakerHalfOpen)
+
+		fail.Store(false)
+		trial := cb.Call()
+		_, err := cb.Call().Get(t.Context())
+		if !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("got err = %v, want %v", err, ErrCircuitOpen)
+		}
+
+		close(release)
+		if _, err := trial.Get(t.Context()); err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		waitState(t, cb, BreakerClosed)
+	})
+	t.Run("state change", func(t *testing.T) {
+		var mu sync.Mutex
+		var changes []string
+		var fail atomic.Bool
+		var calls atomic.Int32
+		fail.Store(true)
+		cb := NewCircuitBreaker(flaky(&fail, &calls), BreakerConfig{
+			MinCalls: 1,
+			Cooldown: 10 * time.Millisecond,
+			OnStateChange: func(from, to BreakerState) {
+				mu.Lock()
+				defer mu.Unlock()
+				changes = append(changes, from.String()+" -> "+to.String())
+			},
+		})
+
+		_, _ = cb.Call().Get(t.Context())
+		waitState(t, cb, BreakerHalfOpen)
+		fail.Store(false)
+		_, _ = cb.Call().Get(t.Context())
+		waitState(t, cb, BreakerClosed)
+
+		mu.Lock()
+		defer mu.Unlock()
+		want := []string{"closed -> open", "open -> half-open", "half-open -> closed"}
+		if len(changes) != len(want) {
+			t.Fatalf("got changes %q, want %q", changes, want)
+		}
+		for i := range want {
+			if changes[i] != want[i] {
+				t.Errorf("got changes %q, want %q", changes, want)
+				break
+			}
+		}
+	})
+	t.Run("func", func(t *testing.T) {
+		var fail atomic.Bool
+		var calls atomic.Int32
+		cb := NewCircuitBreaker(flaky(&fail, &calls), BreakerConfig{})
+		fn := cb.Func()
+		val, err := Await(t.Context(), fn())
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if val != 42 {
+			t.Errorf("got val = %d, want 42", val)
+		}
+	})
+	t.Run("nil function", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("should panic for nil function")
+			}
+		}()
+		NewCircuitBreaker[int](nil, BreakerConfig{})
+	})
+}
@@ -0,0 +1,256 @@
+package azor
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// CacheConfig configures a [Cache].
+// The zero value caches values forever,
+// does not cache errors, and has no size limit.
+type CacheConfig[K comparable] struct {
+	// TTL is how long a loaded value stays fresh.
+	// Zero means values never expire.
+	TTL time.Duration
+
+	// ErrorTTL is how long a load error is cached (negative caching).
+	// During this time, requests for the key reject with the cached
+	// error instead of calling the loader. Zero means errors are not
+	// cached, and the next request calls the loader again.
+	ErrorTTL time.Duration
+
+	// StaleWhileRevalidate is how long an expired value can still
+	// be served after its TTL, while the cache reloads it in the
+	// background. Zero means expired values are never served,
+	// and requests wait for the reload.
+	StaleWhileRevalidate time.Duration
+
+	// MaxSize is the maximum number of keys in the cache.
+	// When the cache is full, it evicts the least recently
+	// used key. Zero means no limit.
+	MaxSize int
+
+	// OnHit is called when a request is served from the cache,
+	// including requests that share an in-flight load. Optional.
+	OnHit func(key K)
+
+	// OnMiss is called when a request is not in the cache
+	// and starts a new load. Optional.
+	OnMiss func(key K)
+
+	// OnLoad is called after a load (including a background
+	// refresh) finishes, with its duration and error. Optional.
+	OnLoad func(key K, d time.Duration, err error)
+
+	// OnEvict is called when a key is evicted
+	// because the cache is full. Optional.
+	OnEvict func(key K)
+}
+
+// Cache is an asynchronous memoizing cache. It loads values with
+// the loader function and caches the results, so that repeated
+// requests for the same key don't call the loader again.
+//
+// Concurrent requests for the same key share a single in-flight
+// load (and its promise), so the loader is never called for the
+// same key more than once at a time.
+//
+// Cache is safe for concurrent use.
+type Cache[K comparable, T any] struct {
+	load func(context.Context, K) (T, error)
+	cfg  CacheConfig[K]
+
+	mu    sync.Mutex
+	items map[K]*list.Element // of *cacheEntry
+	lru   *list.List          // most recently used first
+}
+
+// cacheEntry is a cached result for a key.
+type cacheEntry[K comparable, T any] struct {
+	key        K
+	p          *Promise[T] // in-flight or settled load
+	loaded     bool        // true when the load has finished
+	failed     bool        // true if the load failed
+	expires    time.Time   // zero if never expires
+	refreshing bool        // true while a background refresh runs
+}
+
+// NewCache creates a new cache that loads values with the given function.
+// Panics if the function is nil.
+func NewCache[K comparable, T any](load func(context.Context, K) (T, error), cfg CacheConfig[K]) *Cache[K, T] {
+	if load == nil {
+		panic("azor: nil function")
+	}
+	return &Cache[K, T]{
+		load:  load,
+		cfg:   cfg,
+		items: make(map[K]*list.Element),
+		lru:   list.New(),
+	}
+}
+
+// Get returns a [Promise] that resolves with the value for the key.
+// If the key is cached and fresh, the promise is already settled.
+// If the key is being loaded, returns the in-flight promise.
+// Otherwise, calls the loader asynchronously and caches the result.
+//
+// In stale-while-revalidate mode, an expired value is returned
+// right away, while the cache reloads it in the background.
+//
+// The loader receives a context that carries the values of ctx, but is
+// not canceled with it, since other requests may share the same load.
+// To stop waiting for the value, cancel the context passed to
+// [Promise.Get].
+func (c *Cache[K, T]) Get(ctx context.Context, key K) *Promise[T] {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx = context.WithoutCancel(ctx)
+	var hooks []func()
+
+	c.mu.Lock()
+	p := c.get(ctx, key, time.Now(), &hooks)
+	c.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook()
+	}
+	return p
+}
+
+// Delete removes the key from the cache.
+// Requests that already share the key's load are not affected.
+func (c *Cache[K, T]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		c.remove(elem)
+	}
+}
+
+// Len returns the number of keys in the cache,
+// including the ones being loaded.
+func (c *Cache[K, T]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// get returns the promise for the key, loading it if necessary.
+// Adds the stats hooks to call after the mutex is released.
+// Must be called with the mutex held.
+func (c *Cache[K, T]) get(ctx context.Context, key K, now time.Time, hooks *[]func()) *Promise[T] {
+	if elem, ok := c.items[key]; ok {
+		e := elem.Value.(*cacheEntry[K, T])
+		switch {
+		case !e.loaded || e.expires.IsZero() || now.Before(e.expires):
+			// In-flight or fresh.
+			c.lru.MoveToFront(elem)
+			c.hook(hooks, c.cfg.OnHit, key)
+			return e.p
+		case !e.failed && now.Before(e.expires.Add(c.cfg.StaleWhileRevalidate)):
+			// Stale, but can be served while reloading.
+			c.lru.MoveToFront(elem)
+			c.hook(hooks, c.cfg.OnHit, key)
+			if !e.refreshing {
+				e.refreshing = true
+				c.refresh(ctx, elem)
+			}
+			return e.p
+		default:
+			// Expired.
+			c.remove(elem)
+		}
+	}
+
+	c.hook(hooks, c.cfg.OnMiss, key)
+	e := &cacheEntry[K, T]{key: key}
+	elem := c.lru.PushFront(e)
+	c.items[key] = elem
+	e.p = Run(func() (T, error) {
+		val, err := c.call(ctx, key)
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.items[key] != elem {
+			// Deleted or evicted while loading.
+			return val, err
+		}
+		e.loaded = true
+		switch {
+		case err == nil:
+			e.expires = c.expiry(time.Now(), c.cfg.TTL)
+		case c.cfg.ErrorTTL > 0:
+			e.failed = true
+			e.expires = time.Now().Add(c.cfg.ErrorTTL)
+		default:
+			c.remove(elem)
+		}
+		return val, err
+	})
+
+	if c.cfg.MaxSize > 0 {
+		for c.lru.Len() > c.cfg.MaxSize {
+			oldest := c.lru.Back()
+			c.remove(oldest)
+			c.hook(hooks, c.cfg.OnEvict, oldest.Value.(*cacheEntry[K, T]).key)
+		}
+	}
+	return e.p
+}
+
+// refresh reloads the stale entry in the background.
+// If the reload succeeds, replaces the entry's value.
+// Otherwise, keeps serving the stale value until it expires.
+// Must be called with the mutex held.
+func (c *Cache[K, T]) refresh(ctx context.Context, elem *list.Element) {
+	e := elem.Value.(*cacheEntry[K, T])
+	go func() {
+		val, err := c.call(ctx, e.key)
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		e.refreshing = false
+		if err != nil || c.items[e.key] != elem {
+			return
+		}
+		e.p = resolved(val)
+		e.expires = c.expiry(time.Now(), c.cfg.TTL)
+	}()
+}
+
+// call calls the loader and reports the load
+// to the OnLoad hook.
+func (c *Cache[K, T]) call(ctx context.Context, key K) (T, error) {
+	start := time.Now()
+	val, err := try(func() (T, error) { return c.load(ctx, key) })
+	if c.cfg.OnLoad != nil {
+		c.cfg.OnLoad(key, time.Since(start), err)
+	}
+	return val, err
+}
+
+// remove removes the entry from the cache.
+// Must be called with the mutex held.
+func (c *Cache[K, T]) remove(elem *list.Element) {
+	e := elem.Value.(*cacheEntry[K, T])
+	delete(c.items, e.key)
+	c.lru.Remove(elem)
+}
+
+// expiry returns the expiration time for the given TTL,
+// or zero if the TTL is zero.
+func (c *Cache[K, T]) expiry(now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return now.Add(ttl)
+}
+
+// hook adds a call to the stats hook (if not nil)
+// to the list of hooks.
+func (c *Cache[K, T]) hook(hooks *[]func(), fn func(K), key K) {
+	if fn != nil {
+		*hooks = append(*hooks, func() { fn(key) })
+	}
+}
@@ -0,0 +1,206 @@
+package azor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// counter returns a loader that counts calls and returns
+// the key followed by the call number.
+func counter(delay time.Duration) (func(context.Context, string) (string, error), *atomic.Int32) {
+	var calls atomic.Int32
+	return func(ctx context.Context, key string) (string, error) {
+		n := calls.Add(1)
+		time.Sleep(delay)
+		return key + string(rune('0'+n)), nil
+	}, &calls
+}
+
+func TestCache(t *testing.T) {
+	t.Run("memoize", func(t *testing.T) {
+		load, calls := counter(0)
+		c := NewCache(load, CacheConfig[string]{})
+		for range 3 {
+			val, err := c.Get(t.Context(), "a").Get(t.Context())
+			if err != nil || val != "a1" {
+				t.Errorf("got %v, %v; want a1, nil", val, err)
+			}
+		}
+		if n := calls.Load(); n != 1 {
+			t.Errorf("got %d calls, want 1", n)
+		}
+	})
+	t.Run("singleflight", func(t *testing.T) {
+		load, calls := counter(20 * time.Millisecond)
+		c := NewCache(load, CacheConfig[string]{})
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if val, _ := c.Get(t.Context(), "a").Get(t.Context()); val != "a1" {
+					t.Errorf("got %v, want a1", val)
+				}
+			}()
+		}
+		wg.Wait()
+		if n := calls.Load(); n != 1 {
+			t.Errorf("got %d calls, want 1", n)
+		}
+	})
+	t.Run("ttl", func(t *testing.T) {
+		load, calls := counter(0)
+		c := NewCache(load, CacheConfig[string]{TTL: 20 * time.Millisecond})
+		_, _ = c.Get(t.Context(), "a").Get(t.Context())
+		time.Sleep(40 * time.Millisecond)
+		val, _ := c.Get(t.Context(), "a").Get(t.Context())
+		if val != "a2" {
+			t.Errorf("got %v, want a2", val)
+		}
+		if n := calls.Load(); n != 2 {
+			t.Errorf("got %d calls, want 2", n)
+		}
+	})
+	t.Run("errors not cached", func(t *testing.T) {
+		var calls atomic.Int32
+		errFailed := errors.New("failed")
+		c := NewCache(func(ctx context.Context, key string) (int, error) {
+			calls.Add(1)
+			return 0, errFailed
+		}, CacheConfig[string]{})
+		for range 2 {
+			if _, err := c.Get(t.Context(), "a").Get(t.Context()); !errors.Is(err, errFailed) {
+				t.Errorf("got err = %v, want %v", err, errFailed)
+			}
+		}
+		if n := calls.Load(); n != 2 {
+			t.Errorf("got %d calls, want 2", n)
+		}
+	})
+	t.Run("error ttl", func(t *testing.T) {
+		var calls atomic.Int32
+		errFailed := errors.New("failed")
+		c := NewCache(func(ctx context.Context, key string) (int, error) {
+			calls.Add(1)
+			return 0, errFailed
+		}, CacheConfig[string]{ErrorTTL: 30 * time.Millisecond})
+		for range 2 {
+			if _, err := c.Get(t.Context(), "a").Get(t.Context()); !errors.Is(err, errFailed) {
+				t.Errorf("got err = %v, want %v", err, errFailed)
+			}
+		}
+		if n := calls.Load(); n != 1 {
+			t.Errorf("got %d calls, want 1", n)
+		}
+		time.Sleep(50 * time.Millisecond)
+		_, _ = c.Get(t.Context(), "a").Get(t.Context())
+		if n := calls.Load(); n != 2 {
+			t.Errorf("got %d calls, want 2", n)
+		}
+	})
+	t.Run("stale while revalidate", func(t *testing.T) {
+		load, calls := counter(20 * time.Millisecond)
+		c := NewCache(load, CacheConfig[string]{
+			TTL:                  10 * time.Millisecond,
+			StaleWhileRevalidate: time.Second,
+		})
+		_, _ = c.Get(t.Context(), "a").Get(t.Context())
+		time.Sleep(20 * time.Millisecond)
+
+		// Expired: serves the stale value right away.
+		p := c.Get(t.Context(), "a")
+		select {
+		case <-p.Done():
+		default:
+			t.Fatal("stale value not served right away")
+		}
+		if val, _ := p.Get(t.Context()); val != "a1" {
+			t.Errorf("got %v, want a1", val)
+		}
+		// Refreshes only once.
+		_ = c.Get(t.Context(), "a")
+
+		// Serves the refreshed value later.
+		time.Sleep(50 * time.Millisecond)
+		if n := calls.Load(); n != 2 {
+			t.Errorf("got %d calls, want 2", n)
+		}
+		if val, _ := c.Get(t.Context(), "a").Get(t.Context()); val != "a2" {
+			t.Errorf("got %v, want a2", val)
+		}
+	})
+	t.Run("lru", func(t *testing.T) {
+		load, calls := counter(0)
+		var evicted []string
+		c := NewCache(load, CacheConfig[string]{
+			MaxSize: 2,
+			OnEvict: func(key string) { evicted = append(evicted, key) },
+		})
+		get := func(key string) { _, _ = c.Get(t.Context(), key).Get(t.Context()) }
+		get("a")
+		get("b")
+		get("a") // b is now least recently used
+		get("c") // evicts b
+		if c.Len() != 2 {
+			t.Errorf("got len %d, want 2", c.Len())
+		}
+		if len(evicted) != 1 || evicted[0] != "b" {
+			t.Errorf("got evicted %v, want [b]", evicted)
+		}
+		get("a")
+		if n := calls.Load(); n != 3 {
+			t.Errorf("got %d calls, want 3", n)
+		}
+	})
+	t.Run("stats", func(t *testing.T) {
+		load, _ := counter(0)
+		var hits, misses, loads atomic.Int32
+		c := NewCache(load, CacheConfig[string]{
+			OnHit:  func(string) { hits.Add(1) },
+			OnMiss: func(string) { misses.Add(1) },
+			OnLoad: func(key string, d time.Duration, err error) {
+				if key != "a" || err != nil {
+					t.Errorf("got load %v, %v; want a, nil", key, err)
+				}
+				loads.Add(1)
+			},
+		})
+		for range 3 {
+			_, _ = c.Get(t.Context(), "a").Get(t.Context())
+		}
+		if hits.Load() != 2 || misses.Load() != 1 || loads.Load() != 1 {
+			t.Errorf("got hits=%d misses=%d loads=%d, want 2, 1, 1",
+				hits.Load(), misses.Load(), loads.Load())
+		}
+	})
+	t.Run("delete", func(t *testing.T) {
+		load, calls := counter(0)
+		c := NewCache(load, CacheConfig[string]{})
+		_, _ = c.Get(t.Context(), "a").Get(t.Context())
+		c.Delete("a")
+		if val, _ := c.Get(t.Context(), "a").Get(t.Context()); val != "a2" {
+			t.Errorf("got %v, want a2", val)
+		}
+		if n := calls.Load(); n != 2 {
+			t.Errorf("got %d calls, want 2", n)
+		}
+	})
+	t.Run("canceled caller", func(t *testing.T) {
+		load, _ := counter(20 * time.Millisecond)
+		c := NewCache(load, CacheConfig[string]{})
+		ctx, cancel := context.WithCancel(t.Context())
+		p1 := c.Get(ctx, "a")
+		cancel()
+		// The load is not canceled for other callers.
+		if val, err := c.Get(t.Context(), "a").Get(t.Context()); err != nil || val != "a1" {
+			t.Errorf("got %v, %v; want a1, nil", val, err)
+		}
+		if val, _ := p1.Get(t.Context()); val != "a1" {
+			t.Errorf("got %v, want a1", val)
+		}
+	})
+}
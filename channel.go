@@ -0,0 +1,423 @@
+package azor
+
+import (
+	"cmp"
+	"container/list"
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// Channel is an asynchronous channel, similar to Channel in Kotlin.
+// Instead of blocking, [Channel.Send] and [Channel.Receive] return
+// promises that resolve when the operation completes. Use [Select]
+// to wait for the first of several channel operations.
+//
+// An unbuffered channel (size 0) completes a send only when a receiver
+// takes the value. A buffered channel completes a send as soon as there
+// is room in the buffer. Pending senders and receivers are served
+// in FIFO order.
+//
+// Channel is safe for concurrent use.
+type Channel[T any] struct {
+	chanBase
+	size   int
+	buf    []T       // buffered values, oldest first
+	recvq  list.List // pending receivers (*chanOp)
+	sendq  list.List // pending senders (*chanOp)
+	closed bool
+}
+
+// chanBase is the part of a channel that does not depend
+// on the value type. Select uses it to lock the channels
+// in a consistent order.
+type chanBase struct {
+	id uint64
+	mu sync.Mutex
+}
+
+// chanID is the last assigned channel id.
+var chanID atomic.Uint64
+
+// chanOp is a pending send or receive operation.
+type chanOp[T any] struct {
+	sel  *selection
+	idx  int           // case index in the selection
+	val  T             // value to send or received value
+	err  error         // ErrClosed if the channel is closed
+	elem *list.Element // nil when the operation is not in the queue
+}
+
+// NewChannel creates a new channel with the given buffer size.
+// A size of zero creates an unbuffered channel.
+// Panics if the size is negative.
+func NewChannel[T any](size int) *Channel[T] {
+	if size < 0 {
+		panic("azor: negative channel size")
+	}
+	return &Channel[T]{chanBase: chanBase{id: chanID.Add(1)}, size: size}
+}
+
+// Send sends the value to the channel. Returns a promise that resolves
+// when the value is received (unbuffered channel) or buffered (buffered
+// channel). If the channel is closed (or closes while the send is
+// pending), the promise rejects with [ErrClosed], and the value is
+// not delivered. If the context is canceled first, the promise rejects
+// with the context's error.
+func (c *Channel[T]) Send(ctx context.Context, val T) *Promise[struct{}] {
+	sc := &sendCase[T]{ch: c, val: val}
+	return wait(ctx, []SelectCase{sc}, func(*selection) (struct{}, error) {
+		return struct{}{}, sc.op.err
+	})
+}
+
+// Receive receives a value from the channel. Returns a promise that
+// resolves with the value when it's available. If the channel is closed
+// and there are no buffered values, the promise rejects with [ErrClosed].
+// If the context is canceled first, the promise rejects with the
+// context's error.
+func (c *Channel[T]) Receive(ctx context.Context) *Promise[T] {
+	rc := &recvCase[T]{ch: c}
+	return wait(ctx, []SelectCase{rc}, func(*selection) (T, error) {
+		return rc.op.val, rc.op.err
+	})
+}
+
+// Close closes the channel. Pending receivers and senders reject
+// with [ErrClosed]. Buffered values can still be received.
+// Close is safe to call multiple times.
+func (c *Channel[T]) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	for _, q := range []*list.List{&c.recvq, &c.sendq} {
+		for op := c.claimFrom(q); op != nil; op = c.claimFrom(q) {
+			op.err = ErrClosed
+			op.sel.finish()
+		}
+	}
+}
+
+// Len returns the number of buffered values.
+func (c *Channel[T]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.buf)
+}
+
+// Cap returns the buffer size.
+func (c *Channel[T]) Cap() int {
+	return c.size
+}
+
+// OnReceive returns a [Select] case that receives a value from
+// the channel. If the case is selected, Select calls fn (if not nil)
+// with the value, or with [ErrClosed] if the channel is closed.
+func (c *Channel[T]) OnReceive(fn func(val T, err error)) SelectCase {
+	return &recvCase[T]{ch: c, fn: fn}
+}
+
+// OnSend returns a [Select] case that sends the value to
+// the channel. If the case is selected, Select calls fn (if not nil)
+// with nil, or with [ErrClosed] if the channel is closed.
+func (c *Channel[T]) OnSend(val T, fn func(err error)) SelectCase {
+	return &sendCase[T]{ch: c, val: val, fn: fn}
+}
+
+// trySend completes the send right away if possible:
+// hands the value to a pending receiver or puts it into the buffer.
+// Reports whether the send is completed.
+// Must be called with the mutex held.
+func (c *Channel[T]) trySend(op *chanOp[T]) bool {
+	if c.closed {
+		op.sel.claim(op.idx)
+		op.err = ErrClosed
+		op.sel.finish()
+		return true
+	}
+	if recv := c.claimFrom(&c.recvq); recv != nil {
+		op.sel.claim(op.idx)
+		recv.val = op.val
+		recv.sel.finish()
+		op.sel.finish()
+		return true
+	}
+	if len(c.buf) < c.size {
+		op.sel.claim(op.idx)
+		c.buf = append(c.buf, op.val)
+		op.sel.finish()
+		return true
+	}
+	return false
+}
+
+// tryReceive completes the receive right away if possible:
+// takes a value from the buffer or from a pending sender.
+// Reports whether the receive is completed.
+// Must be called with the mutex held.
+func (c *Channel[T]) tryReceive(op *chanOp[T]) bool {
+	if len(c.buf) > 0 {
+		op.sel.claim(op.idx)
+		op.val = c.buf[0]
+		var zero T
+		c.buf[0] = zero
+		c.buf = c.buf[1:]
+		// Move a pending sender's value into the freed slot.
+		if send := c.claimFrom(&c.sendq); send != nil {
+			c.buf = append(c.buf, send.val)
+			send.sel.finish()
+		}
+		op.sel.finish()
+		return true
+	}
+	if send := c.claimFrom(&c.sendq); send != nil {
+		op.sel.claim(op.idx)
+		op.val = send.val
+		send.sel.finish()
+		op.sel.finish()
+		return true
+	}
+	if c.closed {
+		op.sel.claim(op.idx)
+		op.err = ErrClosed
+		op.sel.finish()
+		return true
+	}
+	return false
+}
+
+// claimFrom removes the pending operations from the queue until
+// it finds one it can claim, and returns it. Returns nil if there are
+// no such operations. Must be called with the mutex held.
+func (c *Channel[T]) claimFrom(q *list.List) *chanOp[T] {
+	for e := q.Front(); e != nil; e = q.Front() {
+		op := q.Remove(e).(*chanOp[T])
+		op.elem = nil
+		if op.sel.claim(op.idx) {
+			return op
+		}
+	}
+	return nil
+}
+
+// dequeue removes the operation from the queue
+// if it's still there.
+func (c *Channel[T]) dequeue(q *list.List, op *chanOp[T]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if op != nil && op.elem != nil {
+		q.Remove(op.elem)
+		op.elem = nil
+	}
+}
+
+// SelectCase is a channel operation for [Select].
+// Use [Channel.OnReceive] or [Channel.OnSend] to create one.
+// A case can only be used in a single Select call.
+type SelectCase interface {
+	// channel returns the channel of the case.
+	channel() *chanBase
+	// try completes the operation right away if possible.
+	// Must be called with the channel locked.
+	try(sel *selection, idx int) bool
+	// enqueue adds the operation to the channel's queue.
+	// Must be called with the channel locked.
+	enqueue()
+	// dequeue removes the operation from the channel's queue.
+	dequeue()
+	// handle calls the case's function after the case is selected.
+	handle()
+}
+
+// recvCase is a receive operation.
+type recvCase[T any] struct {
+	ch *Channel[T]
+	fn func(T, error)
+	op *chanOp[T]
+}
+
+func (rc *recvCase[T]) channel() *chanBase {
+	return &rc.ch.chanBase
+}
+
+func (rc *recvCase[T]) try(sel *selection, idx int) bool {
+	rc.op = &chanOp[T]{sel: sel, idx: idx}
+	return rc.ch.tryReceive(rc.op)
+}
+
+func (rc *recvCase[T]) enqueue() {
+	rc.op.elem = rc.ch.recvq.PushBack(rc.op)
+}
+
+func (rc *recvCase[T]) dequeue() {
+	rc.ch.dequeue(&rc.ch.recvq, rc.op)
+}
+
+func (rc *recvCase[T]) handle() {
+	if rc.fn != nil {
+		rc.fn(rc.op.val, rc.op.err)
+	}
+}
+
+// sendCase is a send operation.
+type sendCase[T any] struct {
+	ch  *Channel[T]
+	val T
+	fn  func(error)
+	op  *chanOp[T]
+}
+
+func (sc *sendCase[T]) channel() *chanBase {
+	return &sc.ch.chanBase
+}
+
+func (sc *sendCase[T]) try(sel *selection, idx int) bool {
+	sc.op = &chanOp[T]{sel: sel, idx: idx, val: sc.val}
+	return sc.ch.trySend(sc.op)
+}
+
+func (sc *sendCase[T]) enqueue() {
+	sc.op.elem = sc.ch.sendq.PushBack(sc.op)
+}
+
+func (sc *sendCase[T]) dequeue() {
+	sc.ch.dequeue(&sc.ch.sendq, sc.op)
+}
+
+func (sc *sendCase[T]) handle() {
+	if sc.fn != nil {
+		sc.fn(sc.op.err)
+	}
+}
+
+// Select waits for the first of the channel operations to complete,
+// similar to the select statement. If several operations can complete
+// right away, Select picks the first one in the order of the cases.
+// Only the selected operation takes effect: the other values are
+// not sent or received.
+//
+// Returns a promise that resolves with the index of the selected case,
+// after calling the case's function. If the context is canceled first,
+// the promise rejects with the context's error, and none of the
+// operations take effect.
+//
+// Panics if there are no cases.
+func Select(ctx context.Context, cases ...SelectCase) *Promise[int] {
+	if len(cases) == 0 {
+		panic("azor: no cases")
+	}
+	return wait(ctx, cases, func(sel *selection) (int, error) {
+		cases[sel.idx].handle()
+		return sel.idx, nil
+	})
+}
+
+// selection coordinates the cases of a single Select
+// (or a single Send or Receive), so that only one of them
+// completes.
+type selection struct {
+	state atomic.Int32 // selPending, selDone or selCanceled
+	idx   int          // index of the completed case
+	err   error        // context's error if canceled
+	done  chan struct{}
+}
+
+const (
+	selPending int32 = iota
+	selDone
+	selCanceled
+)
+
+// claim marks the case with the given index as completed.
+// Reports whether it succeeded (no other case completed,
+// and the selection is not canceled).
+func (s *selection) claim(idx int) bool {
+	if !s.state.CompareAndSwap(selPending, selDone) {
+		return false
+	}
+	s.idx = idx
+	return true
+}
+
+// finish signals that the claimed case has its result.
+func (s *selection) finish() {
+	close(s.done)
+}
+
+// wait registers the cases in their channels and asynchronously waits
+// for one of them to complete or the context to cancel. Returns a promise
+// that resolves with the result function's result for the completed case,
+// or rejects with the context's error.
+func wait[T any](ctx context.Context, cases []SelectCase, result func(*selection) (T, error)) *Promise[T] {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	sel := &selection{done: make(chan struct{})}
+
+	// Lock the channels in the order of their ids,
+	// so that concurrent selects don't deadlock.
+	var chans []*chanBase
+	for _, c := range cases {
+		chans = append(chans, c.channel())
+	}
+	slices.SortFunc(chans, func(a, b *chanBase) int { return cmp.Compare(a.id, b.id) })
+	chans = slices.Compact(chans)
+	lockAll := func() {
+		for _, ch := range chans {
+			ch.mu.Lock()
+		}
+	}
+	unlockAll := func() {
+		for _, ch := range slices.Backward(chans) {
+			ch.mu.Unlock()
+		}
+	}
+
+	lockAll()
+	completed := false
+	for i, c := range cases {
+		if completed = c.try(sel, i); completed {
+			break
+		}
+	}
+	if !completed {
+		if err := ctx.Err(); err != nil {
+			sel.state.Store(selCanceled)
+			sel.err = context.Cause(ctx)
+			sel.finish()
+		} else {
+			for _, c := range cases {
+				c.enqueue()
+			}
+		}
+	}
+	unlockAll()
+
+	stop := func() bool { return false }
+	if !completed && sel.err == nil {
+		stop = context.AfterFunc(ctx, func() {
+			lockAll()
+			defer unlockAll()
+			if sel.state.CompareAndSwap(selPending, selCanceled) {
+				sel.err = context.Cause(ctx)
+				sel.finish()
+			}
+		})
+	}
+	return Run(func() (T, error) {
+		<-sel.done
+		stop()
+		for _, c := range cases {
+			c.dequeue()
+		}
+		if sel.err != nil {
+			var zero T
+			return zero, sel.err
+		}
+		return result(sel)
+	})
+}
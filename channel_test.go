@@ -0,0 +1,263 @@
+package azor
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// isSettled reports whether the promise settles within a short time.
+func isSettled[T any](p *Promise[T]) bool {
+	select {
+	case <-p.Done():
+		return true
+	case <-time.After(10 * time.Millisecond):
+		return false
+	}
+}
+
+func TestChannel(t *testing.T) {
+	t.Run("unbuffered", func(t *testing.T) {
+		ch := NewChannel[int](0)
+		sent := ch.Send(t.Context(), 42)
+		if isSettled(sent) {
+			t.Error("send should wait for receiver")
+		}
+		val, err := ch.Receive(t.Context()).Get(t.Context())
+		if err != nil || val != 42 {
+			t.Errorf("got %v, %v; want 42, nil", val, err)
+		}
+		if !isSettled(sent) {
+			t.Error("send should complete after receive")
+		}
+	})
+	t.Run("receive first", func(t *testing.T) {
+		ch := NewChannel[int](0)
+		recv := ch.Receive(t.Context())
+		if isSettled(recv) {
+			t.Error("receive should wait for sender")
+		}
+		if _, err := ch.Send(t.Context(), 42).Get(t.Context()); err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if val, _ := recv.Get(t.Context()); val != 42 {
+			t.Errorf("got %v, want 42", val)
+		}
+	})
+	t.Run("buffered", func(t *testing.T) {
+		ch := NewChannel[int](2)
+		if !isSettled(ch.Send(t.Context(), 1)) || !isSettled(ch.Send(t.Context(), 2)) {
+			t.Error("sends should complete while buffer has room")
+		}
+		third := ch.Send(t.Context(), 3)
+		if isSettled(third) {
+			t.Error("send should wait when buffer is full")
+		}
+		if ch.Len() != 2 || ch.Cap() != 2 {
+			t.Errorf("got len %d, cap %d; want 2, 2", ch.Len(), ch.Cap())
+		}
+		var got []int
+		for range 3 {
+			val, _ := ch.Receive(t.Context()).Get(t.Context())
+			got = append(got, val)
+		}
+		if !slices.Equal(got, []int{1, 2, 3}) {
+			t.Errorf("got %v, want [1 2 3]", got)
+		}
+		if !isSettled(third) {
+			t.Error("pending send should complete")
+		}
+	})
+	t.Run("fifo", func(t *testing.T) {
+		ch := NewChannel[int](0)
+		var recvs []*Promise[int]
+		for range 3 {
+			recvs = append(recvs, ch.Receive(t.Context()))
+		}
+		for i := range 3 {
+			_, _ = ch.Send(t.Context(), i).Get(t.Context())
+		}
+		for i, p := range recvs {
+			if val, _ := p.Get(t.Context()); val != i {
+				t.Errorf("receiver %d: got %v, want %v", i, val, i)
+			}
+		}
+	})
+	t.Run("close", func(t *testing.T) {
+		ch := NewChannel[int](1)
+		recv := ch.Receive(t.Context())
+		ch.Close()
+		if _, err := recv.Get(t.Context()); !errors.Is(err, ErrClosed) {
+			t.Errorf("got err = %v, want %v", err, ErrClosed)
+		}
+		if _, err := ch.Send(t.Context(), 1).Get(t.Context()); !errors.Is(err, ErrClosed) {
+			t.Errorf("got err = %v, want %v", err, ErrClosed)
+		}
+		ch.Close()
+	})
+	t.Run("close pending sender", func(t *testing.T) {
+		ch := NewChannel[int](0)
+		sent := ch.Send(t.Context(), 1)
+		ch.Close()
+		if _, err := sent.Get(t.Context()); !errors.Is(err, ErrClosed) {
+			t.Errorf("got err = %v, want %v", err, ErrClosed)
+		}
+	})
+	t.Run("drain after close", func(t *testing.T) {
+		ch := NewChannel[int](2)
+		ch.Send(t.Context(), 1)
+		ch.Close()
+		if val, err := ch.Receive(t.Context()).Get(t.Context()); err != nil || val != 1 {
+			t.Errorf("got %v, %v; want 1, nil", val, err)
+		}
+		if _, err := ch.Receive(t.Context()).Get(t.Context()); !errors.Is(err, ErrClosed) {
+			t.Errorf("got err = %v, want %v", err, ErrClosed)
+		}
+	})
+	t.Run("cancel", func(t *testing.T) {
+		ch := NewChannel[int](0)
+		ctx, cancel := context.WithCancel(t.Context())
+		recv := ch.Receive(ctx)
+		cancel()
+		if _, err := recv.Get(t.Context()); !errors.Is(err, context.Canceled) {
+			t.Errorf("got err = %v, want %v", err, context.Canceled)
+		}
+		// The canceled receiver does not take the value.
+		sent := ch.Send(t.Context(), 1)
+		if isSettled(sent) {
+			t.Error("send should wait for a live receiver")
+		}
+		if val, _ := ch.Receive(t.Context()).Get(t.Context()); val != 1 {
+			t.Errorf("got %v, want 1", val)
+		}
+	})
+	t.Run("concurrent", func(t *testing.T) {
+		ch := NewChannel[int](3)
+		var wg sync.WaitGroup
+		for i := range 50 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = ch.Send(t.Context(), i).Get(t.Context())
+			}()
+		}
+		sum := 0
+		for range 50 {
+			val, _ := ch.Receive(t.Context()).Get(t.Context())
+			sum += val
+		}
+		wg.Wait()
+		if sum != 49*50/2 {
+			t.Errorf("got sum %d, want %d", sum, 49*50/2)
+		}
+	})
+}
+
+func TestSelect(t *testing.T) {
+	t.Run("ready", func(t *testing.T) {
+		a, b := NewChannel[int](1), NewChannel[string](1)
+		b.Send(t.Context(), "hi")
+		var got string
+		idx, err := Select(t.Context(),
+			a.OnReceive(func(int, error) { t.Error("a should not be selected") }),
+			b.OnReceive(func(val string, err error) { got = val }),
+		).Get(t.Context())
+		if err != nil || idx != 1 {
+			t.Errorf("got %v, %v; want 1, nil", idx, err)
+		}
+		if got != "hi" {
+			t.Errorf("got %q, want hi", got)
+		}
+	})
+	t.Run("first ready wins", func(t *testing.T) {
+		a, b := NewChannel[int](1), NewChannel[int](1)
+		a.Send(t.Context(), 1)
+		b.Send(t.Context(), 2)
+		idx, _ := Select(t.Context(), a.OnReceive(nil), b.OnReceive(nil)).Get(t.Context())
+		if idx != 0 {
+			t.Errorf("got %v, want 0", idx)
+		}
+		// The other value is not taken.
+		if b.Len() != 1 {
+			t.Errorf("got len %d, want 1", b.Len())
+		}
+	})
+	t.Run("wait", func(t *testing.T) {
+		a, b := NewChannel[int](0), NewChannel[int](0)
+		p := Select(t.Context(), a.OnReceive(nil), b.OnReceive(nil))
+		if isSettled(p) {
+			t.Error("select should wait")
+		}
+		_, _ = b.Send(t.Context(), 2).Get(t.Context())
+		if idx, _ := p.Get(t.Context()); idx != 1 {
+			t.Errorf("got %v, want 1", idx)
+		}
+		// The other case is no longer pending.
+		if isSettled(a.Send(t.Context(), 1)) {
+			t.Error("send should not be taken by a finished select")
+		}
+	})
+	t.Run("send", func(t *testing.T) {
+		full, free := NewChannel[int](1), NewChannel[int](1)
+		full.Send(t.Context(), 0)
+		var sendErr error = errors.New("not called")
+		idx, _ := Select(t.Context(),
+			full.OnSend(1, nil),
+			free.OnSend(2, func(err error) { sendErr = err }),
+		).Get(t.Context())
+		if idx != 1 || sendErr != nil {
+			t.Errorf("got %v, %v; want 1, nil", idx, sendErr)
+		}
+		if val, _ := free.Receive(t.Context()).Get(t.Context()); val != 2 {
+			t.Errorf("got %v, want 2", val)
+		}
+	})
+	t.Run("closed", func(t *testing.T) {
+		ch := NewChannel[int](0)
+		p := Select(t.Context(), ch.OnReceive(func(_ int, err error) {
+			if !errors.Is(err, ErrClosed) {
+				t.Errorf("got err = %v, want %v", err, ErrClosed)
+			}
+		}))
+		ch.Close()
+		if idx, err := p.Get(t.Context()); err != nil || idx != 0 {
+			t.Errorf("got %v, %v; want 0, nil", idx, err)
+		}
+	})
+	t.Run("cancel", func(t *testing.T) {
+		ch := NewChannel[int](0)
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+		_, err := Select(ctx, ch.OnReceive(nil)).Get(t.Context())
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got err = %v, want %v", err, context.DeadlineExceeded)
+		}
+	})
+	t.Run("select vs select", func(t *testing.T) {
+		a, b := NewChannel[int](0), NewChannel[int](0)
+		for range 100 {
+			p1 := Select(t.Context(), a.OnSend(1, nil), b.OnReceive(nil))
+			p2 := Select(t.Context(), b.OnSend(2, nil), a.OnReceive(nil))
+			i1, err1 := p1.Get(t.Context())
+			i2, err2 := p2.Get(t.Context())
+			if err1 != nil || err2 != nil {
+				t.Fatalf("got errors %v, %v", err1, err2)
+			}
+			// Either a or b carried the value, in both selects.
+			if i1 != 1-i2 {
+				t.Fatalf("got indexes %d, %d; want matching", i1, i2)
+			}
+		}
+	})
+	t.Run("no cases", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("want panic")
+			}
+		}()
+		Select(t.Context())
+	})
+}
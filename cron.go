@@ -0,0 +1,238 @@
+package azor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSpec is a parsed cron expression. Use [ParseCron] to create one.
+type CronSpec struct {
+	minute, hour, dom, month, dow uint64 // bit sets of allowed values
+	domStar, dowStar              bool   // true if the field is "*"
+	loc                           *time.Location
+}
+
+// cronField describes a field of a cron expression.
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	cronMinute = cronField{name: "minute", min: 0, max: 59}
+	cronHour   = cronField{name: "hour", min: 0, max: 23}
+	cronDom    = cronField{name: "day of month", min: 1, max: 31}
+	cronMonth  = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	cronDow = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// cronMacros are the predefined schedules.
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a standard five-field cron expression:
+//
+//	minute hour day-of-month month day-of-week
+//
+// Each field is a "*", a value, a range ("1-5"), or a list of them
+// ("1,3,10-12"), with an optional step ("*/15", "0-30/10").
+// Months and days of week can be given by their three-letter names
+// ("jan", "mon"), case-insensitive. Both 0 and 7 mean Sunday.
+// If both day fields are restricted (not "*"), a day matches if
+// either field matches, as in the standard cron.
+//
+// The expression can also be one of the macros: @yearly (@annually),
+// @monthly, @weekly, @daily (@midnight) or @hourly.
+//
+// The times are in the given location (time.Local if nil), unless
+// the expression starts with a "CRON_TZ=<zone>" or "TZ=<zone>" prefix,
+// such as "CRON_TZ=Europe/Berlin 0 9 * * mon-fri".
+func ParseCron(expr string, loc *time.Location) (*CronSpec, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	spec, err := parseCron(expr, loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+	}
+	return spec, nil
+}
+
+// parseCron parses a cron expression.
+func parseCron(expr string, loc *time.Location) (*CronSpec, error) {
+	expr = strings.TrimSpace(expr)
+	if zone, ok := cutPrefix(expr, "CRON_TZ=", "TZ="); ok {
+		name, rest, _ := strings.Cut(zone, " ")
+		var err error
+		if loc, err = time.LoadLocation(name); err != nil {
+			return nil, err
+		}
+		expr = strings.TrimSpace(rest)
+	}
+	if macro, ok := cronMacros[strings.ToLower(expr)]; ok {
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("got %d fields, want 5", len(fields))
+	}
+	spec := &CronSpec{loc: loc}
+	var err error
+	if spec.minute, err = cronMinute.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if spec.hour, err = cronHour.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if spec.dom, err = cronDom.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if spec.month, err = cronMonth.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if spec.dow, err = cronDow.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	if spec.dow&(1<<7) != 0 {
+		// 7 is Sunday too.
+		spec.dow |= 1
+	}
+	spec.domStar = strings.HasPrefix(fields[2], "*")
+	spec.dowStar = strings.HasPrefix(fields[4], "*")
+	return spec, nil
+}
+
+// parse parses the field into a bit set of allowed values.
+func (f cronField) parse(s string) (uint64, error) {
+	var bits uint64
+	for part := range strings.SplitSeq(s, ",") {
+		lo, hi, step, err := f.parseRange(part)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", f.name, err)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// parseRange parses a part of the field: a "*", a value
+// or a range, with an optional step.
+func (f cronField) parseRange(s string) (lo, hi, step int, err error) {
+	rng, stepStr, hasStep := strings.Cut(s, "/")
+	step = 1
+	if hasStep {
+		if step, err = strconv.Atoi(stepStr); err != nil || step <= 0 {
+			return 0, 0, 0, fmt.Errorf("invalid step %q", stepStr)
+		}
+	}
+
+	switch loStr, hiStr, isRange := strings.Cut(rng, "-"); {
+	case rng == "*":
+		lo, hi = f.min, f.max
+	case isRange:
+		if lo, err = f.value(loStr); err != nil {
+			return 0, 0, 0, err
+		}
+		if hi, err = f.value(hiStr); err != nil {
+			return 0, 0, 0, err
+		}
+		if lo > hi {
+			return 0, 0, 0, fmt.Errorf("invalid range %q", rng)
+		}
+	default:
+		if lo, err = f.value(rng); err != nil {
+			return 0, 0, 0, err
+		}
+		hi = lo
+		if hasStep {
+			// "5/10" means "5-max/10".
+			hi = f.max
+		}
+	}
+	return lo, hi, step, nil
+}
+
+// value parses a single value of the field.
+func (f cronField) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Next returns the earliest time after t that matches the schedule,
+// in the schedule's location. Local times that don't exist because
+// of a daylight saving time change are skipped, and the ones that
+// repeat match both times. Returns the zero time if there is no such
+// time within five years (for example, "0 0 30 2 *").
+func (c *CronSpec) Next(t time.Time) time.Time {
+	// Skip the non-matching months and days by the calendar,
+	// and the hours and minutes by the clock, so that the time
+	// keeps moving forward through a repeated hour.
+	t = t.In(c.loc)
+	t = t.Add(time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+	limit := t.Year() + 5
+	for t.Year() <= limit {
+		y, m, d := t.Date()
+		switch {
+		case c.month&(1<<uint(m)) == 0:
+			t = time.Date(y, m+1, 1, 0, 0, 0, 0, c.loc)
+		case !c.dayMatches(t):
+			t = time.Date(y, m, d+1, 0, 0, 0, 0, c.loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches reports whether the day of t matches the day
+// of month and day of week fields.
+func (c *CronSpec) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// cutPrefix returns s without the first of the prefixes it starts with,
+// and reports whether it found one.
+func cutPrefix(s string, prefixes ...string) (string, bool) {
+	for _, prefix := range prefixes {
+		if after, ok := strings.CutPrefix(s, prefix); ok {
+			return after, true
+		}
+	}
+	return s, false
+}
@@ -0,0 +1,128 @@
+package azor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	// 2026-01-01 is a Thursday.
+	start := time.Date(2026, 1, 1, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 1, 1, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 1, 1, 10, 15, 0, 0, time.UTC)},
+		{"5 * * * *", time.Date(2026, 1, 1, 11, 5, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2026, 1, 1, 13, 0, 0, 0, time.UTC)},
+		{"30 8 * * *", time.Date(2026, 1, 2, 8, 30, 0, 0, time.UTC)},
+		{"0 0 * * mon", time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * SAT,SUN", time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 0 15 * *", time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 mar *", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Either day field matches when both are restricted.
+		{"0 0 15 * fri", time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"10/20 * * * *", time.Date(2026, 1, 1, 10, 10, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			spec, err := ParseCron(test.expr, time.UTC)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := spec.Next(start); !got.Equal(test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseCron_location(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no time zone database")
+	}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	want := time.Date(2026, 1, 1, 9, 0, 0, 0, berlin) // 08:00 UTC
+
+	t.Run("param", func(t *testing.T) {
+		spec, _ := ParseCron("0 9 * * *", berlin)
+		if got := spec.Next(start); !got.Equal(want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+	t.Run("prefix", func(t *testing.T) {
+		spec, err := ParseCron("CRON_TZ=Europe/Berlin 0 9 * * *", time.UTC)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := spec.Next(start); !got.Equal(want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+	t.Run("dst", func(t *testing.T) {
+		// 02:30 does not exist on 2026-03-29 in Berlin,
+		// so that day is skipped.
+		spec, _ := ParseCron("30 2 * * *", berlin)
+		got := spec.Next(time.Date(2026, 3, 29, 0, 0, 0, 0, berlin))
+		if want := time.Date(2026, 3, 30, 2, 30, 0, 0, berlin); !got.Equal(want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+	t.Run("dst fall back", func(t *testing.T) {
+		// 01:00-01:59 repeats on 2024-11-03 in New York,
+		// and the time keeps moving forward through it.
+		newYork, err := time.LoadLocation("America/New_York")
+		if err != nil {
+			t.Skip("no time zone database")
+		}
+		spec, _ := ParseCron("* * * * *", newYork)
+		start := time.Date(2024, 11, 3, 6, 10, 0, 0, time.UTC) // 01:10 EST
+		if got, want := spec.Next(start), start.Add(time.Minute); !got.Equal(want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+		// The repeated times match both times.
+		spec, _ = ParseCron("30 1 * * *", newYork)
+		first := spec.Next(time.Date(2024, 11, 3, 0, 0, 0, 0, newYork))
+		second := spec.Next(first)
+		if want := first.Add(time.Hour); !second.Equal(want) {
+			t.Errorf("got %v, %v; want an hour apart", first, second)
+		}
+		if third := spec.Next(second); third.Day() != 4 {
+			t.Errorf("got %v, want the next day", third)
+		}
+	})
+}
+
+func TestParseCron_invalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"x * * * *",
+		"* * * foo *",
+		"TZ=Nowhere/Nothing * * * * *",
+	}
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := ParseCron(expr, nil); err == nil {
+				t.Error("want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package azor
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DebounceOptions configures [Debounce].
+// The zero value calls the function on the trailing edge only.
+type DebounceOptions struct {
+	// Leading calls the function on the leading edge,
+	// at the first call of a burst.
+	Leading bool
+
+	// Trailing calls the function on the trailing edge,
+	// after the burst is over. If neither Leading nor Trailing
+	// is set, Trailing is assumed.
+	Trailing bool
+
+	// MaxWait is the maximum time the function can be delayed
+	// since its last call, so that a steady stream of calls
+	// still calls it periodically. Zero means no limit.
+	MaxWait time.Duration
+}
+
+// ThrottleOptions configures [Throttle].
+// The zero value calls the function on both edges.
+type ThrottleOptions struct {
+	// Leading calls the function on the leading edge
+	// of the interval.
+	Leading bool
+
+	// Trailing calls the function on the trailing edge
+	// of the interval, if there were calls after the leading one.
+	// If neither Leading nor Trailing is set, both are assumed.
+	Trailing bool
+}
+
+// Debounce returns an asynchronous function that delays calling fn
+// until the given time has passed since the last call. A series of
+// calls closer together than the wait time (a burst) results in
+// a single call to fn.
+//
+// Calls collapsed together share the same [Promise]: the one of
+// the fn call that they are collapsed into. On the trailing edge,
+// it's the call at the end of the burst. If only the leading edge
+// is enabled, it's the call at the start of the burst. If both are
+// enabled, the first call of a burst gets the leading call's promise,
+// and the rest get the trailing call's promise. The trailing call
+// only happens if there were calls after the leading one.
+//
+// Panics if the function is nil.
+func Debounce[T any](fn AsyncFunc[T], wait time.Duration, opt DebounceOptions) AsyncFunc[T] {
+	if fn == nil {
+		panic("azor: nil function")
+	}
+	if !opt.Leading && !opt.Trailing {
+		opt.Trailing = true
+	}
+	if opt.MaxWait > 0 {
+		opt.MaxWait = max(opt.MaxWait, wait)
+	}
+	d := &debouncer[T]{fn: fn, wait: wait, opt: opt}
+	return d.call
+}
+
+// Throttle returns an asynchronous function that calls fn at most
+// once per interval. It's a [Debounce] with the maximum wait equal
+// to the interval.
+//
+// Calls collapsed together share the same [Promise]: the one of the
+// fn call that they are collapsed into. See [Debounce] for details.
+//
+// Panics if the function is nil.
+func Throttle[T any](fn AsyncFunc[T], interval time.Duration, opt ThrottleOptions) AsyncFunc[T] {
+	if !opt.Leading && !opt.Trailing {
+		opt.Leading, opt.Trailing = true, true
+	}
+	return Debounce(fn, interval, DebounceOptions{
+		Leading:  opt.Leading,
+		Trailing: opt.Trailing,
+		MaxWait:  interval,
+	})
+}
+
+// debouncer collapses the calls to a function.
+type debouncer[T any] struct {
+	fn   AsyncFunc[T]
+	wait time.Duration
+	opt  DebounceOptions
+
+	mu         sync.Mutex
+	timer      *time.Timer // nil if no burst is in progress
+	burst      uint64      // number of started timers, to ignore stale ones
+	lastCall   time.Time   // zero before the first call
+	lastInvoke time.Time   // when fn was last called
+	lead       *Promise[T] // promise of the last leading call
+	trail      *invocation[T]
+}
+
+// invocation is a pending call to fn.
+type invocation[T any] struct {
+	p       *Promise[T]
+	resolve func(T)
+	reject  func(error)
+}
+
+// call registers a call and returns the promise
+// of the fn call that it's collapsed into.
+func (d *debouncer[T]) call() *Promise[T] {
+	d.mu.Lock()
+	now := time.Now()
+	invoking := d.shouldInvoke(now)
+
+	// The burst is over, but the timer hasn't handled it yet.
+	// End the burst here, so that the call starts a new one
+	// instead of joining the old one.
+	var last *invocation[T]
+	if d.timer != nil && !d.lastCall.IsZero() && now.Sub(d.lastCall) >= d.wait {
+		d.timer.Stop()
+		d.timer = nil
+		last = d.trail
+		d.trail = nil
+	}
+	d.lastCall = now
+
+	var run *invocation[T]
+	var p *Promise[T]
+	switch {
+	case invoking && d.timer == nil:
+		// Leading edge: start a new burst.
+		d.lastInvoke = now
+		d.startTimer()
+		if d.opt.Leading {
+			run = newInvocation[T]()
+			d.lead = run.p
+			p = run.p
+		} else {
+			p = d.pending().p
+		}
+	case invoking && d.opt.MaxWait > 0:
+		// The burst is too long, call fn now.
+		d.lastInvoke = now
+		d.timer.Reset(d.wait)
+		run = d.pending()
+		d.trail = nil
+		d.lead = run.p
+		p = run.p
+	default:
+		if d.timer == nil {
+			d.startTimer()
+		}
+		if d.opt.Trailing {
+			p = d.pending().p
+		} else {
+			p = d.lead
+		}
+	}
+	d.mu.Unlock()
+
+	if last != nil {
+		d.invoke(last)
+	}
+	if run != nil {
+		d.invoke(run)
+	}
+	return p
+}
+
+// startTimer starts the timer for a new burst.
+// Must be called with the mutex held.
+func (d *debouncer[T]) startTimer() {
+	d.burst++
+	burst := d.burst
+	d.timer = time.AfterFunc(d.wait, func() { d.expire(burst) })
+}
+
+// expire handles the timer of the given burst: ends the burst
+// with the trailing call if it's time, or restarts the timer
+// for the remaining wait.
+func (d *debouncer[T]) expire(burst uint64) {
+	d.mu.Lock()
+	if burst != d.burst || d.timer == nil {
+		// The burst was ended by a call.
+		d.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	if !d.shouldInvoke(now) {
+		d.timer.Reset(d.remaining(now))
+		d.mu.Unlock()
+		return
+	}
+	d.timer = nil
+	run := d.trail
+	d.trail = nil
+	if run != nil {
+		d.lastInvoke = now
+	}
+	d.mu.Unlock()
+
+	if run != nil {
+		d.invoke(run)
+	}
+}
+
+// shouldInvoke reports whether fn should be called at the given time:
+// either the burst is over, or the maximum wait has passed.
+// Must be called with the mutex held.
+func (d *debouncer[T]) shouldInvoke(now time.Time) bool {
+	if d.lastCall.IsZero() || now.Sub(d.lastCall) >= d.wait {
+		return true
+	}
+	return d.opt.MaxWait > 0 && now.Sub(d.lastInvoke) >= d.opt.MaxWait
+}
+
+// remaining returns the time until fn should be called.
+// Must be called with the mutex held.
+func (d *debouncer[T]) remaining(now time.Time) time.Duration {
+	wait := d.wait - now.Sub(d.lastCall)
+	if d.opt.MaxWait > 0 {
+		wait = min(wait, d.opt.MaxWait-now.Sub(d.lastInvoke))
+	}
+	return wait
+}
+
+// pending returns the pending trailing call,
+// creating it if necessary.
+// Must be called with the mutex held.
+func (d *debouncer[T]) pending() *invocation[T] {
+	if d.trail == nil {
+		d.trail = newInvocation[T]()
+	}
+	return d.trail
+}
+
+// invoke calls fn and settles the invocation's promise
+// with the result.
+func (d *debouncer[T]) invoke(run *invocation[T]) {
+	go func() {
+		val, err := try(func() (T, error) {
+			return d.fn().Get(context.Background())
+		})
+		if err != nil {
+			run.reject(err)
+		} else {
+			run.resolve(val)
+		}
+	}()
+}
+
+// newInvocation creates a new pending call.
+func newInvocation[T any]() *invocation[T] {
+	p, resolve, reject := WithResolvers[T]()
+	return &invocation[T]{p: p, resolve: resolve, reject: reject}
+}
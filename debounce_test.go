@@ -0,0 +1,193 @@
+package azor
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// numbered returns an asynchronous function that
+// returns the number of times it has been called.
+func numbered(calls *atomic.Int32) AsyncFunc[int] {
+	return Async(func() (int, error) {
+		return int(calls.Add(1)), nil
+	})
+}
+
+func TestDebounce(t *testing.T) {
+	t.Run("trailing", func(t *testing.T) {
+		var calls atomic.Int32
+		fn := Debounce(numbered(&calls), 20*time.Millisecond, DebounceOptions{})
+		p1 := fn()
+		time.Sleep(5 * time.Millisecond)
+		p2 := fn()
+		time.Sleep(5 * time.Millisecond)
+		p3 := fn()
+		if p1 != p2 || p2 != p3 {
+			t.Error("want the same promise for collapsed calls")
+		}
+		if n := calls.Load(); n != 0 {
+			t.Errorf("got %d calls before the trailing edge, want 0", n)
+		}
+		val, err := p3.Get(t.Context())
+		if val != 1 || err != nil {
+			t.Errorf("got %d, %v; want 1, nil", val, err)
+		}
+
+		// A new burst gets a new promise.
+		time.Sleep(30 * time.Millisecond)
+		p4 := fn()
+		if p4 == p3 {
+			t.Error("want a new promise for the next burst")
+		}
+		if val, _ := p4.Get(t.Context()); val != 2 {
+			t.Errorf("got %d, want 2", val)
+		}
+	})
+	t.Run("leading", func(t *testing.T) {
+		var calls atomic.Int32
+		fn := Debounce(numbered(&calls), 20*time.Millisecond, DebounceOptions{Leading: true})
+		p1 := fn()
+		p2 := fn()
+		if p1 != p2 {
+			t.Error("want the same promise for collapsed calls")
+		}
+		if val, _ := p1.Get(t.Context()); val != 1 {
+			t.Errorf("got %d, want 1", val)
+		}
+		time.Sleep(40 * time.Millisecond)
+		if n := calls.Load(); n != 1 {
+			t.Errorf("got %d calls, want 1", n)
+		}
+	})
+	t.Run("leading late timer", func(t *testing.T) {
+		var calls atomic.Int32
+		d := &debouncer[int]{fn: numbered(&calls), wait: 10 * time.Millisecond, opt: DebounceOptions{Leading: true}}
+		p1 := d.call()
+
+		// The burst is over, but the timer callback is late.
+		d.mu.Lock()
+		d.timer.Stop()
+		d.mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+
+		p2 := d.call()
+		if p2 == p1 {
+			t.Error("want a new promise for the next burst")
+		}
+		if val, _ := p2.Get(t.Context()); val != 2 {
+			t.Errorf("got %d, want 2", val)
+		}
+	})
+	t.Run("leading and trailing", func(t *testing.T) {
+		var calls atomic.Int32
+		fn := Debounce(numbered(&calls), 20*time.Millisecond, DebounceOptions{Leading: true, Trailing: true})
+		p1 := fn()
+		p2 := fn()
+		p3 := fn()
+		if p1 == p2 || p2 != p3 {
+			t.Error("want the leading promise first, then the trailing one")
+		}
+		if val, _ := p1.Get(t.Context()); val != 1 {
+			t.Errorf("leading: got %d, want 1", val)
+		}
+		if val, _ := p3.Get(t.Context()); val != 2 {
+			t.Errorf("trailing: got %d, want 2", val)
+		}
+
+		// No trailing call without calls after the leading one.
+		time.Sleep(30 * time.Millisecond)
+		fn()
+		time.Sleep(40 * time.Millisecond)
+		if n := calls.Load(); n != 3 {
+			t.Errorf("got %d calls, want 3", n)
+		}
+	})
+	t.Run("max wait", func(t *testing.T) {
+		var calls atomic.Int32
+		fn := Debounce(numbered(&calls), 20*time.Millisecond, DebounceOptions{MaxWait: 50 * time.Millisecond})
+		start := time.Now()
+		first := fn()
+		for time.Since(start) < 120*time.Millisecond {
+			fn()
+			time.Sleep(5 * time.Millisecond)
+		}
+		// The calls never pause for 20ms, but fn is still
+		// called every 50ms.
+		if n := calls.Load(); n < 2 {
+			t.Errorf("got %d calls, want at least 2", n)
+		}
+		if val, _ := first.Get(t.Context()); val != 1 {
+			t.Errorf("got %d, want 1", val)
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		fn := Debounce(Async(func() (int, error) {
+			return 0, errDummy
+		}), time.Millisecond, DebounceOptions{})
+		if _, err := fn().Get(t.Context()); !errors.Is(err, errDummy) {
+			t.Errorf("got %v, want %v", err, errDummy)
+		}
+	})
+	t.Run("panic", func(t *testing.T) {
+		fn := Debounce(func() *Promise[int] { panic("boom") }, time.Millisecond, DebounceOptions{})
+		if _, err := fn().Get(t.Context()); err == nil {
+			t.Error("want error")
+		}
+	})
+	t.Run("nil function", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("want panic")
+			}
+		}()
+		Debounce[int](nil, time.Millisecond, DebounceOptions{})
+	})
+}
+
+func TestThrottle(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		var calls atomic.Int32
+		fn := Throttle(numbered(&calls), 30*time.Millisecond, ThrottleOptions{})
+		p1 := fn()
+		p2 := fn()
+		p3 := fn()
+		if p1 == p2 || p2 != p3 {
+			t.Error("want the leading promise first, then the trailing one")
+		}
+		if val, _ := p1.Get(t.Context()); val != 1 {
+			t.Errorf("leading: got %d, want 1", val)
+		}
+		if val, _ := p3.Get(t.Context()); val != 2 {
+			t.Errorf("trailing: got %d, want 2", val)
+		}
+	})
+	t.Run("steady calls", func(t *testing.T) {
+		var calls atomic.Int32
+		fn := Throttle(numbered(&calls), 30*time.Millisecond, ThrottleOptions{})
+		start := time.Now()
+		for time.Since(start) < 100*time.Millisecond {
+			fn()
+			time.Sleep(2 * time.Millisecond)
+		}
+		time.Sleep(50 * time.Millisecond)
+		// About one call per interval.
+		if n := calls.Load(); n < 3 || n > 6 {
+			t.Errorf("got %d calls, want 3-6", n)
+		}
+	})
+	t.Run("leading only", func(t *testing.T) {
+		var calls atomic.Int32
+		fn := Throttle(numbered(&calls), 20*time.Millisecond, ThrottleOptions{Leading: true})
+		p1 := fn()
+		p2 := fn()
+		if p1 != p2 {
+			t.Error("want the same promise for collapsed calls")
+		}
+		time.Sleep(40 * time.Millisecond)
+		if n := calls.Load(); n != 1 {
+			t.Errorf("got %d calls, want 1", n)
+		}
+	})
+}
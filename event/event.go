@@ -0,0 +1,278 @@
+// Package event provides a typed event emitter whose subscriptions
+// can be consumed as callbacks, promises or asynchronous sequences.
+//
+// Use it to adapt callback-based code to promise-based APIs:
+//
+//	em := event.NewEmitter[Message]()
+//	// Somewhere in the callback-based code:
+//	em.Emit("message", msg)
+//	// In the promise-based code:
+//	msg, err := em.Once(ctx, "message").Get(ctx)
+package event
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/nalgeon/azor"
+)
+
+// Listener is a registered event handler.
+// Use it to remove the handler with [Emitter.Off].
+type Listener struct {
+	name string
+}
+
+// Emitter emits named events with values of type E
+// to the registered listeners, similar to EventEmitter in Node.js.
+//
+// Emitter is safe for concurrent use.
+type Emitter[E any] struct {
+	mu        sync.Mutex
+	listeners map[string][]handler[E]
+	closers   map[*Listener]func() // called when the emitter closes
+	closed    bool
+}
+
+// handler is a listener with its function.
+type handler[E any] struct {
+	l  *Listener
+	fn func(E)
+}
+
+// NewEmitter creates a new emitter.
+func NewEmitter[E any]() *Emitter[E] {
+	return &Emitter[E]{
+		listeners: make(map[string][]handler[E]),
+		closers:   make(map[*Listener]func()),
+	}
+}
+
+// On registers the function to be called each time the named event
+// is emitted, and returns the listener. Listeners are called in the
+// order they were registered. If the emitter is closed, the function
+// is never called.
+//
+// Panics if the function is nil.
+func (em *Emitter[E]) On(name string, fn func(E)) *Listener {
+	if fn == nil {
+		panic("event: nil function")
+	}
+	em.mu.Lock()
+	defer em.mu.Unlock()
+	return em.on(name, fn, nil)
+}
+
+// Off removes the listener, so its function is no longer called.
+// Does nothing if the listener is already removed.
+func (em *Emitter[E]) Off(l *Listener) {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+	em.off(l)
+}
+
+// Emit calls the listeners of the named event synchronously with
+// the given value, in the order they were registered. Returns the
+// number of listeners called. Does nothing if the emitter is closed.
+func (em *Emitter[E]) Emit(name string, val E) int {
+	em.mu.Lock()
+	handlers := slices.Clone(em.listeners[name])
+	em.mu.Unlock()
+	for _, h := range handlers {
+		h.fn(val)
+	}
+	return len(handlers)
+}
+
+// Once returns a promise that resolves with the value of the next
+// emission of the named event. The promise rejects with [azor.ErrClosed]
+// if the emitter closes first, or with the context's error if the
+// context is canceled first.
+func (em *Emitter[E]) Once(ctx context.Context, name string) *azor.Promise[E] {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	p, resolve, reject := azor.WithResolvers[E]()
+	if err := ctx.Err(); err != nil {
+		reject(context.Cause(ctx))
+		return p
+	}
+
+	em.mu.Lock()
+	defer em.mu.Unlock()
+	var l *Listener
+	stop := func() bool { return false }
+	l = em.on(name, func(val E) {
+		em.Off(l)
+		stop()
+		resolve(val)
+	}, func() {
+		stop()
+		reject(azor.ErrClosed)
+	})
+	if l == nil {
+		reject(azor.ErrClosed)
+		return p
+	}
+	stop = context.AfterFunc(ctx, func() {
+		em.Off(l)
+		reject(context.Cause(ctx))
+	})
+	return p
+}
+
+// Events returns a sequence of the values of the named event,
+// emitted after the call to Events. The values are buffered until
+// the consumer pulls them, so Emit never waits for the consumer.
+//
+// The sequence ends when the emitter closes (after the buffered
+// values are consumed) or when the context is canceled.
+// Closing the sequence removes its listener.
+func (em *Emitter[E]) Events(ctx context.Context, name string) *azor.AsyncSeq[E] {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	q := &queue[E]{signal: make(chan struct{}, 1)}
+
+	em.mu.Lock()
+	l := em.on(name, q.push, q.close)
+	em.mu.Unlock()
+	if l == nil {
+		q.close()
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		em.Off(l)
+		q.close()
+	})
+	return azor.FuncSeq(q.pop, func() {
+		stop()
+		em.Off(l)
+	})
+}
+
+// ListenerCount returns the number of listeners of the named event.
+func (em *Emitter[E]) ListenerCount(name string) int {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+	return len(em.listeners[name])
+}
+
+// Close removes all the listeners. Pending [Emitter.Once] promises
+// reject with [azor.ErrClosed], and [Emitter.Events] sequences end.
+// Close is safe to call multiple times.
+func (em *Emitter[E]) Close() {
+	em.mu.Lock()
+	if em.closed {
+		em.mu.Unlock()
+		return
+	}
+	em.closed = true
+	closers := em.closers
+	em.listeners = nil
+	em.closers = nil
+	em.mu.Unlock()
+
+	for _, closeFn := range closers {
+		closeFn()
+	}
+}
+
+// on registers the function and the close callback (if not nil).
+// Returns nil if the emitter is closed.
+// Must be called with the mutex held.
+func (em *Emitter[E]) on(name string, fn func(E), onClose func()) *Listener {
+	if em.closed {
+		return nil
+	}
+	l := &Listener{name: name}
+	em.listeners[name] = append(em.listeners[name], handler[E]{l: l, fn: fn})
+	if onClose != nil {
+		em.closers[l] = onClose
+	}
+	return l
+}
+
+// off removes the listener.
+// Must be called with the mutex held.
+func (em *Emitter[E]) off(l *Listener) {
+	if l == nil || em.closed {
+		return
+	}
+	handlers := em.listeners[l.name]
+	idx := slices.IndexFunc(handlers, func(h handler[E]) bool { return h.l == l })
+	if idx < 0 {
+		return
+	}
+	// Don't modify the slice in place, since Emit
+	// may be iterating over a copy of its header.
+	handlers = slices.Delete(slices.Clone(handlers), idx, idx+1)
+	if len(handlers) == 0 {
+		delete(em.listeners, l.name)
+	} else {
+		em.listeners[l.name] = handlers
+	}
+	delete(em.closers, l)
+}
+
+// queue buffers the emitted values for a sequence.
+type queue[E any] struct {
+	mu     sync.Mutex
+	items  []E
+	closed bool
+	signal chan struct{} // signaled when the queue changes
+}
+
+// push adds the value to the queue.
+func (q *queue[E]) push(val E) {
+	q.mu.Lock()
+	if !q.closed {
+		q.items = append(q.items, val)
+	}
+	q.mu.Unlock()
+	q.notify()
+}
+
+// close marks the queue as closed, so that pop
+// returns ErrEnd after the buffered values.
+func (q *queue[E]) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.notify()
+}
+
+// pop waits for the next value and removes it from the queue.
+func (q *queue[E]) pop(ctx context.Context) (E, error) {
+	var zero E
+	for {
+		q.mu.Lock()
+		if len(q.items) > 0 {
+			val := q.items[0]
+			q.items[0] = zero
+			q.items = q.items[1:]
+			q.mu.Unlock()
+			return val, nil
+		}
+		closed := q.closed
+		q.mu.Unlock()
+		if closed {
+			return zero, azor.ErrEnd
+		}
+
+		select {
+		case <-q.signal:
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// notify wakes up the waiting pop (if any).
+func (q *queue[E]) notify() {
+	select {
+	case q.signal <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,184 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nalgeon/azor"
+)
+
+func TestEmitter(t *testing.T) {
+	t.Run("on and emit", func(t *testing.T) {
+		em := NewEmitter[int]()
+		var got []int
+		em.On("num", func(n int) { got = append(got, n) })
+		em.On("num", func(n int) { got = append(got, n*10) })
+		em.On("other", func(n int) { t.Error("other should not be called") })
+		if n := em.Emit("num", 1); n != 2 {
+			t.Errorf("got %d listeners called, want 2", n)
+		}
+		if !slices.Equal(got, []int{1, 10}) {
+			t.Errorf("got %v, want [1 10]", got)
+		}
+	})
+	t.Run("off", func(t *testing.T) {
+		em := NewEmitter[int]()
+		calls := 0
+		l := em.On("num", func(int) { calls++ })
+		em.Emit("num", 1)
+		em.Off(l)
+		em.Off(l)
+		em.Emit("num", 2)
+		if calls != 1 {
+			t.Errorf("got %d calls, want 1", calls)
+		}
+		if n := em.ListenerCount("num"); n != 0 {
+			t.Errorf("got %d listeners, want 0", n)
+		}
+	})
+	t.Run("off while emitting", func(t *testing.T) {
+		em := NewEmitter[int]()
+		var l1 *Listener
+		calls := 0
+		l1 = em.On("num", func(int) { calls++; em.Off(l1) })
+		em.On("num", func(int) { calls++ })
+		em.Emit("num", 1)
+		em.Emit("num", 2)
+		if calls != 3 {
+			t.Errorf("got %d calls, want 3", calls)
+		}
+	})
+	t.Run("close", func(t *testing.T) {
+		em := NewEmitter[int]()
+		em.On("num", func(int) { t.Error("should not be called after close") })
+		em.Close()
+		em.Close()
+		if n := em.Emit("num", 1); n != 0 {
+			t.Errorf("got %d listeners called, want 0", n)
+		}
+		em.On("num", func(int) { t.Error("should not be called after close") })
+		em.Emit("num", 1)
+	})
+	t.Run("nil function", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("want panic")
+			}
+		}()
+		NewEmitter[int]().On("num", nil)
+	})
+}
+
+func TestOnce(t *testing.T) {
+	t.Run("next emission", func(t *testing.T) {
+		em := NewEmitter[string]()
+		p := em.Once(t.Context(), "msg")
+		em.Emit("msg", "hello")
+		em.Emit("msg", "world")
+		val, err := p.Get(t.Context())
+		if err != nil || val != "hello" {
+			t.Errorf("got %v, %v; want hello, nil", val, err)
+		}
+		if n := em.ListenerCount("msg"); n != 0 {
+			t.Errorf("got %d listeners, want 0", n)
+		}
+	})
+	t.Run("close", func(t *testing.T) {
+		em := NewEmitter[string]()
+		p := em.Once(t.Context(), "msg")
+		em.Close()
+		if _, err := p.Get(t.Context()); !errors.Is(err, azor.ErrClosed) {
+			t.Errorf("got err = %v, want %v", err, azor.ErrClosed)
+		}
+		p = em.Once(t.Context(), "msg")
+		if _, err := p.Get(t.Context()); !errors.Is(err, azor.ErrClosed) {
+			t.Errorf("got err = %v, want %v", err, azor.ErrClosed)
+		}
+	})
+	t.Run("cancel", func(t *testing.T) {
+		em := NewEmitter[string]()
+		ctx, cancel := context.WithCancel(t.Context())
+		p := em.Once(ctx, "msg")
+		cancel()
+		if _, err := p.Get(t.Context()); !errors.Is(err, context.Canceled) {
+			t.Errorf("got err = %v, want %v", err, context.Canceled)
+		}
+		time.Sleep(10 * time.Millisecond)
+		if n := em.ListenerCount("msg"); n != 0 {
+			t.Errorf("got %d listeners, want 0", n)
+		}
+	})
+	t.Run("concurrent emit", func(t *testing.T) {
+		em := NewEmitter[int]()
+		p := em.Once(t.Context(), "num")
+		var wg sync.WaitGroup
+		for i := range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				em.Emit("num", i)
+			}()
+		}
+		wg.Wait()
+		if _, err := p.Get(t.Context()); err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+	})
+}
+
+func TestEvents(t *testing.T) {
+	t.Run("values", func(t *testing.T) {
+		em := NewEmitter[int]()
+		seq := em.Events(t.Context(), "num")
+		for i := range 3 {
+			em.Emit("num", i)
+		}
+		em.Close()
+
+		var got []int
+		for val, err := range seq.All(t.Context()) {
+			if err != nil {
+				t.Fatalf("got err = %v, want nil", err)
+			}
+			got = append(got, val)
+		}
+		if !slices.Equal(got, []int{0, 1, 2}) {
+			t.Errorf("got %v, want [0 1 2]", got)
+		}
+	})
+	t.Run("wait", func(t *testing.T) {
+		em := NewEmitter[int]()
+		seq := em.Events(t.Context(), "num")
+		defer seq.Close()
+		p := seq.Next(t.Context())
+		time.Sleep(10 * time.Millisecond)
+		em.Emit("num", 42)
+		if val, err := p.Get(t.Context()); err != nil || val != 42 {
+			t.Errorf("got %v, %v; want 42, nil", val, err)
+		}
+	})
+	t.Run("cancel", func(t *testing.T) {
+		em := NewEmitter[int]()
+		ctx, cancel := context.WithCancel(t.Context())
+		seq := em.Events(ctx, "num")
+		cancel()
+		if _, err := seq.Next(t.Context()).Get(t.Context()); !errors.Is(err, azor.ErrEnd) {
+			t.Errorf("got err = %v, want %v", err, azor.ErrEnd)
+		}
+		if n := em.ListenerCount("num"); n != 0 {
+			t.Errorf("got %d listeners, want 0", n)
+		}
+	})
+	t.Run("close sequence", func(t *testing.T) {
+		em := NewEmitter[int]()
+		seq := em.Events(t.Context(), "num")
+		seq.Close()
+		if n := em.ListenerCount("num"); n != 0 {
+			t.Errorf("got %d listeners, want 0", n)
+		}
+	})
+}
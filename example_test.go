@@ -2,6 +2,7 @@ package azor_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -24,6 +25,50 @@ func ExampleAsync() {
 	// 42 <nil>
 }
 
+func ExampleAsyncSeq_All() {
+	// Values arrive over time.
+	ch := make(chan string)
+	go func() {
+		for _, s := range []string{"one", "two", "three"} {
+			time.Sleep(time.Millisecond)
+			ch <- s
+		}
+		close(ch)
+	}()
+
+	// Range over them like "for await" in JavaScript.
+	seq := azor.ChanSeq(ch)
+	for val, err := range seq.All(context.Background()) {
+		if err != nil {
+			fmt.Println(err)
+			break
+		}
+		fmt.Println(val)
+	}
+
+	// Output:
+	// one
+	// two
+	// three
+}
+
+func ExamplePromise_Chan() {
+	p := azor.Run(func() (int, error) {
+		time.Sleep(10 * time.Millisecond)
+		return 42, nil
+	})
+
+	select {
+	case res := <-p.Chan():
+		fmt.Printf("val = %v, err = %v\n", res.Val, res.Err)
+	case <-time.After(time.Second):
+		fmt.Println("timeout")
+	}
+
+	// Output:
+	// val = 42, err = <nil>
+}
+
 func ExamplePromise_Get() {
 	p := azor.Run(func() (int, error) {
 		time.Sleep(10 * time.Millisecond)
@@ -66,6 +111,30 @@ func ExamplePromise_Get_cancel() {
 	// val = 0, err = context deadline exceeded
 }
 
+func ExampleRetry() {
+	// Fail the first two calls.
+	calls := 0
+	fetch := func(ctx context.Context) (string, error) {
+		calls++
+		if calls < 3 {
+			return "", errors.New("temporary failure")
+		}
+		return "done", nil
+	}
+
+	policy := azor.RetryPolicy{
+		Backoff:     azor.ExponentialBackoff(time.Millisecond, 10*time.Millisecond),
+		MaxAttempts: 5,
+	}
+
+	ctx := context.Background()
+	val, err := azor.Retry(ctx, policy, fetch).Get(ctx)
+	fmt.Printf("val = %v, err = %v, calls = %d\n", val, err, calls)
+
+	// Output:
+	// val = done, err = <nil>, calls = 3
+}
+
 func ExampleRun() {
 	// Run calls the given function asynchronously
 	// and returns a promise.
@@ -81,3 +150,28 @@ func ExampleRun() {
 	// Output:
 	// val = 42, err = <nil>
 }
+
+func ExampleWithScope() {
+	ctx := context.Background()
+	err := azor.WithScope(ctx, func(s *azor.Scope) error {
+		user := azor.Go(s, func(ctx context.Context) (string, error) {
+			return "alice", nil
+		})
+		orders := azor.Go(s, func(ctx context.Context) (int, error) {
+			return 0, errors.New("orders unavailable")
+		})
+
+		name, err := user.Get(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Println("user:", name)
+		_, _ = orders.Get(ctx)
+		return nil
+	})
+	fmt.Println("err:", err)
+
+	// Output:
+	// user: alice
+	// err: orders unavailable
+}
@@ -0,0 +1,334 @@
+// Package fair provides a scheduler that shares a fixed number
+// of workers between tenants using weighted fair queuing, so that
+// a tenant with many tasks cannot starve the others.
+package fair
+
+import (
+	"container/list"
+	"context"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/nalgeon/azor"
+	"github.com/nalgeon/azor/internal/safe"
+)
+
+// Config configures a [Scheduler].
+// Zero fields are replaced with the defaults.
+type Config struct {
+	// Workers is the number of tasks that run concurrently.
+	// Default is runtime.GOMAXPROCS(0).
+	Workers int
+}
+
+// Stats are the metrics of a tenant.
+type Stats struct {
+	// Queued is the number of tasks waiting for a worker.
+	Queued int
+	// Running is the number of tasks being run.
+	Running int
+	// Started is the number of tasks that have started running.
+	Started int
+	// TotalWait is the total time the started tasks
+	// have spent in the queue.
+	TotalWait time.Duration
+	// MaxWait is the longest time a started task
+	// has spent in the queue.
+	MaxWait time.Duration
+}
+
+// AvgWait returns the average time the started tasks
+// have spent in the queue.
+func (s Stats) AvgWait() time.Duration {
+	if s.Started == 0 {
+		return 0
+	}
+	return s.TotalWait / time.Duration(s.Started)
+}
+
+// Scheduler runs tasks from different tenants on a fixed number of
+// workers. Each tenant has its own FIFO queue, and the workers serve
+// the queues by weighted fair queuing: over time, each backlogged
+// tenant gets a share of the workers proportional to the weight
+// of its tasks, no matter how many tasks it has queued.
+//
+// Scheduler is safe for concurrent use.
+type Scheduler[K comparable, T any] struct {
+	mu      sync.Mutex
+	cond    *sync.Cond // signaled when a task is queued or the scheduler is closed
+	tenants map[K]*tenant[K, T]
+	active  map[*tenant[K, T]]struct{} // tenants with queued tasks
+	vtime   float64                    // virtual time
+	seq     uint64                     // number of submitted tasks
+	closed  bool
+	wg      sync.WaitGroup // running workers
+}
+
+// tenant is the queue and stats of a tenant.
+type tenant[K comparable, T any] struct {
+	key     K
+	queue   list.List // of *task
+	finish  float64   // finish tag of the last queued task
+	started float64   // finish tag of the last started task
+	stats   Stats
+}
+
+// task is a queued task.
+type task[K comparable, T any] struct {
+	ctx     context.Context
+	fn      func(context.Context) (T, error)
+	tenant  *tenant[K, T]
+	start   float64 // virtual start tag
+	finish  float64 // virtual finish tag
+	seq     uint64  // breaks ties between equal finish tags
+	queued  time.Time
+	elem    *list.Element // nil if not in the queue
+	stop    func() bool   // stops the cancellation callback
+	resolve func(T)
+	reject  func(error)
+}
+
+// New creates a new scheduler and starts its workers.
+// Call [Scheduler.Shutdown] to stop them.
+func New[K comparable, T any](cfg Config) *Scheduler[K, T] {
+	if cfg.Workers <= 0 {
+		cfg.Workers = runtime.GOMAXPROCS(0)
+	}
+	s := &Scheduler[K, T]{
+		tenants: make(map[K]*tenant[K, T]),
+		active:  make(map[*tenant[K, T]]struct{}),
+	}
+	s.cond = sync.NewCond(&s.mu)
+	s.wg.Add(cfg.Workers)
+	for range cfg.Workers {
+		go func() {
+			defer s.wg.Done()
+			s.work()
+		}()
+	}
+	return s
+}
+
+// Submit queues the function to run on behalf of the tenant with the
+// given weight. While both have tasks queued, a tenant submitting
+// tasks with weight 2 gets twice as many tasks run as a tenant
+// submitting tasks with weight 1. Non-positive weights are treated as 1.
+//
+// Returns a [azor.Promise] that resolves with the function's result.
+// The function receives the given context. If the context is canceled
+// while the task is queued, the task is removed from the queue, and the
+// promise rejects with the context's error. If the scheduler is shut
+// down, the promise rejects with [azor.ErrClosed].
+//
+// Panics if the function is nil.
+func (s *Scheduler[K, T]) Submit(ctx context.Context, key K, weight float64, fn func(context.Context) (T, error)) *azor.Promise[T] {
+	if fn == nil {
+		panic("fair: nil function")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if weight <= 0 {
+		weight = 1
+	}
+	p, resolve, reject := azor.WithResolvers[T]()
+	if err := ctx.Err(); err != nil {
+		reject(context.Cause(ctx))
+		return p
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		reject(azor.ErrClosed)
+		return p
+	}
+	tn, ok := s.tenants[key]
+	if !ok {
+		tn = &tenant[K, T]{key: key}
+		s.tenants[key] = tn
+	}
+
+	// A tenant that was idle starts from the current virtual time,
+	// so it does not get extra share for the time it was idle.
+	t := &task[K, T]{
+		ctx:     ctx,
+		fn:      fn,
+		tenant:  tn,
+		start:   max(s.vtime, tn.finish),
+		queued:  time.Now(),
+		resolve: resolve,
+		reject:  reject,
+	}
+	t.finish = t.start + 1/weight
+	s.seq++
+	t.seq = s.seq
+	tn.finish = t.finish
+	t.elem = tn.queue.PushBack(t)
+	tn.stats.Queued++
+	s.active[tn] = struct{}{}
+
+	t.stop = context.AfterFunc(ctx, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if t.elem != nil {
+			s.remove(t)
+			t.reject(context.Cause(ctx))
+		}
+	})
+	s.cond.Signal()
+	return p
+}
+
+// Stats returns the metrics of the tenant.
+func (s *Scheduler[K, T]) Stats(key K) Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if tn, ok := s.tenants[key]; ok {
+		return tn.stats
+	}
+	return Stats{}
+}
+
+// AllStats returns the metrics of all the tenants
+// that have submitted tasks (and have not been forgotten,
+// see [Scheduler.Forget]).
+func (s *Scheduler[K, T]) AllStats() map[K]Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := make(map[K]Stats, len(s.tenants))
+	for key, tn := range s.tenants {
+		stats[key] = tn.stats
+	}
+	return stats
+}
+
+// Forget removes the metrics and the state of the tenant, unless
+// it has queued or running tasks. Reports whether the tenant was
+// removed. The scheduler keeps every tenant that has submitted tasks,
+// so call Forget for the ones that are gone to free the memory.
+func (s *Scheduler[K, T]) Forget(key K) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tn, ok := s.tenants[key]
+	if !ok || tn.stats.Queued > 0 || tn.stats.Running > 0 {
+		return false
+	}
+	delete(s.tenants, key)
+	return true
+}
+
+// Shutdown stops accepting new tasks and stops the workers after
+// the queued tasks are handled according to the mode. Returns
+// a [azor.Promise] that resolves when all the workers have stopped.
+// Shutdown is safe to call multiple times.
+func (s *Scheduler[K, T]) Shutdown(mode azor.ShutdownMode) *azor.Promise[struct{}] {
+	s.mu.Lock()
+	s.closed = true
+	if mode == azor.ShutdownDiscard {
+		for tn := range s.active {
+			for tn.queue.Len() > 0 {
+				t := tn.queue.Front().Value.(*task[K, T])
+				s.dequeue(t)
+				t.stop()
+				t.reject(azor.ErrClosed)
+			}
+			tn.finish = tn.started
+		}
+	}
+	s.cond.Broadcast()
+	s.mu.Unlock()
+
+	return azor.Run(func() (struct{}, error) {
+		s.wg.Wait()
+		return struct{}{}, nil
+	})
+}
+
+// work runs the queued tasks until the scheduler
+// is closed and there are no queued tasks.
+func (s *Scheduler[K, T]) work() {
+	for {
+		s.mu.Lock()
+		for len(s.active) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		t := s.next()
+		if t == nil {
+			s.mu.Unlock()
+			return
+		}
+		s.dequeue(t)
+		s.vtime = max(s.vtime, t.start)
+		t.tenant.started = t.finish
+		stats := &t.tenant.stats
+		wait := time.Since(t.queued)
+		stats.Running++
+		stats.Started++
+		stats.TotalWait += wait
+		stats.MaxWait = max(stats.MaxWait, wait)
+		s.mu.Unlock()
+
+		t.stop()
+		val, err := safe.Call(func() (T, error) { return t.fn(t.ctx) })
+		if err != nil {
+			t.reject(err)
+		} else {
+			t.resolve(val)
+		}
+
+		s.mu.Lock()
+		stats.Running--
+		s.mu.Unlock()
+	}
+}
+
+// next returns the queued task with the smallest finish tag,
+// or nil if there are no queued tasks. Since each tenant's queue
+// is ordered by finish tags, only the heads of the queues are
+// considered. Must be called with the mutex held.
+func (s *Scheduler[K, T]) next() *task[K, T] {
+	var next *task[K, T]
+	for tn := range s.active {
+		t := tn.queue.Front().Value.(*task[K, T])
+		if next == nil || t.finish < next.finish ||
+			t.finish == next.finish && t.seq < next.seq {
+			next = t
+		}
+	}
+	return next
+}
+
+// dequeue removes the task from its tenant's queue.
+// Must be called with the mutex held.
+func (s *Scheduler[K, T]) dequeue(t *task[K, T]) {
+	tn := t.tenant
+	tn.queue.Remove(t.elem)
+	t.elem = nil
+	tn.stats.Queued--
+	if tn.queue.Len() == 0 {
+		delete(s.active, tn)
+	}
+}
+
+// remove removes the task from its tenant's queue without running it,
+// and refunds its share: the tasks queued after it move up, as if
+// it had never been submitted. Must be called with the mutex held.
+func (s *Scheduler[K, T]) remove(t *task[K, T]) {
+	tn := t.tenant
+	prev := tn.started
+	if e := t.elem.Prev(); e != nil {
+		prev = e.Value.(*task[K, T]).finish
+	}
+	next := t.elem.Next()
+	s.dequeue(t)
+	for e := next; e != nil; e = e.Next() {
+		u := e.Value.(*task[K, T])
+		cost := u.finish - u.start
+		u.start = max(s.vtime, prev)
+		u.finish = u.start + cost
+		prev = u.finish
+	}
+	tn.finish = prev
+}
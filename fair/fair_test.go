@@ -0,0 +1,295 @@
+package fair
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nalgeon/azor"
+)
+
+// fixture is a single-worker scheduler that records the order
+// in which the tenants' tasks run. The worker can be held with
+// a task of the "gate" tenant, so that the other tasks queue up.
+type fixture struct {
+	t     *testing.T
+	s     *Scheduler[string, string]
+	gate  chan struct{}
+	order chan string
+	n     map[string]int // number of tasks submitted by each tenant
+}
+
+func newFixture(t *testing.T) *fixture {
+	f := &fixture{
+		t:     t,
+		s:     New[string, string](Config{Workers: 1}),
+		gate:  make(chan struct{}),
+		order: make(chan string, 100),
+		n:     make(map[string]int),
+	}
+	t.Cleanup(func() { f.s.Shutdown(azor.ShutdownDiscard) })
+	return f
+}
+
+// hold occupies the worker with a task of the "gate" tenant
+// until [fixture.run] is called.
+func (f *fixture) hold() {
+	gate := f.gate
+	f.s.Submit(context.Background(), "gate", 1, func(context.Context) (string, error) {
+		<-gate
+		return "", nil
+	})
+	for f.s.Stats("gate").Running == 0 {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// submit queues n tasks of the tenant, labeled with
+// the tenant's key and the task's number ("a1", "a2", ...).
+func (f *fixture) submit(ctx context.Context, key string, weight float64, n int) []*azor.Promise[string] {
+	var ps []*azor.Promise[string]
+	for range n {
+		f.n[key]++
+		label := fmt.Sprintf("%s%d", key, f.n[key])
+		ps = append(ps, f.s.Submit(ctx, key, weight, func(context.Context) (string, error) {
+			f.order <- label
+			return label, nil
+		}))
+	}
+	return ps
+}
+
+// run opens the gate, waits for the queued tasks to finish,
+// and returns their labels in the order they ran.
+// Checks that each tenant's tasks ran in the order they were submitted.
+func (f *fixture) run() []string {
+	f.t.Helper()
+	close(f.gate)
+	f.s.Shutdown(azor.ShutdownDrain).Get(context.Background())
+	close(f.order)
+	var order []string
+	next := make(map[string]int)
+	for label := range f.order {
+		key := strings.TrimRight(label, "0123456789")
+		next[key]++
+		if want := fmt.Sprintf("%s%d", key, next[key]); label != want {
+			f.t.Errorf("got %s, want %s: tenant's tasks out of order", label, want)
+		}
+		order = append(order, label)
+	}
+	return order
+}
+
+// tenants returns the tenant keys of the labels.
+func tenants(labels []string) []string {
+	keys := make([]string, len(labels))
+	for i, label := range labels {
+		keys[i] = strings.TrimRight(label, "0123456789")
+	}
+	return keys
+}
+
+func TestScheduler(t *testing.T) {
+	ctx := context.Background()
+	t.Run("fair", func(t *testing.T) {
+		f := newFixture(t)
+		f.hold()
+		f.submit(ctx, "noisy", 1, 4)
+		f.submit(ctx, "quiet", 1, 2)
+		got := tenants(f.run())
+		want := []string{"noisy", "quiet", "noisy", "quiet", "noisy", "noisy"}
+		if !slices.Equal(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+	t.Run("weighted", func(t *testing.T) {
+		f := newFixture(t)
+		f.hold()
+		for range 6 {
+			f.submit(ctx, "heavy", 2, 1)
+			f.submit(ctx, "light", 1, 1)
+		}
+		// While both are backlogged, heavy gets twice as many turns.
+		got := tenants(f.run())[:6]
+		want := []string{"heavy", "light", "heavy", "heavy", "light", "heavy"}
+		if !slices.Equal(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+	t.Run("idle tenant", func(t *testing.T) {
+		// Old runs its tasks alone, then new arrives and takes turns
+		// with old instead of catching up on old's past share.
+		f := newFixture(t)
+		for _, p := range f.submit(ctx, "old", 1, 3) {
+			p.Get(ctx)
+		}
+		f.hold()
+		for range 2 {
+			f.submit(ctx, "new", 1, 1)
+			f.submit(ctx, "old", 1, 1)
+		}
+		got := f.run()
+		want := []string{"old1", "old2", "old3", "new1", "old4", "new2", "old5"}
+		if !slices.Equal(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+	t.Run("stats", func(t *testing.T) {
+		f := newFixture(t)
+		f.hold()
+		f.submit(ctx, "a", 1, 2)
+		if st := f.s.Stats("a"); st.Queued != 2 || st.Running != 0 || st.Started != 0 {
+			t.Errorf("got %+v, want 2 queued", st)
+		}
+		if st := f.s.Stats("gate"); st.Running != 1 || st.Started != 1 {
+			t.Errorf("got %+v, want 1 running", st)
+		}
+		time.Sleep(10 * time.Millisecond)
+		f.run()
+
+		all := f.s.AllStats()
+		if len(all) != 2 {
+			t.Errorf("got %d tenants, want 2", len(all))
+		}
+		st := all["a"]
+		if st.Queued != 0 || st.Running != 0 || st.Started != 2 {
+			t.Errorf("got %+v, want 2 started", st)
+		}
+		if st.MaxWait < 10*time.Millisecond || st.AvgWait() < 5*time.Millisecond {
+			t.Errorf("got max wait %v, avg wait %v; want at least 10ms and 5ms", st.MaxWait, st.AvgWait())
+		}
+		if st := f.s.Stats("unknown"); st != (Stats{}) {
+			t.Errorf("got %+v, want zero stats", st)
+		}
+	})
+	t.Run("cancel queued", func(t *testing.T) {
+		f := newFixture(t)
+		f.hold()
+		cctx, cancel := context.WithCancel(ctx)
+		canceled := f.submit(cctx, "a", 1, 2)
+		f.submit(ctx, "b", 1, 1)
+		cancel()
+		for _, p := range canceled {
+			if _, err := p.Get(ctx); !errors.Is(err, context.Canceled) {
+				t.Errorf("got %v, want context.Canceled", err)
+			}
+		}
+		if st := f.s.Stats("a"); st.Queued != 0 {
+			t.Errorf("got %d queued, want 0", st.Queued)
+		}
+		if got := f.run(); !slices.Equal(got, []string{"b1"}) {
+			t.Errorf("got %v, want [b1]", got)
+		}
+		if st := f.s.Stats("a"); st.Started != 0 {
+			t.Errorf("got %d started, want 0", st.Started)
+		}
+	})
+	t.Run("cancel refund", func(t *testing.T) {
+		// Canceled tasks don't count towards a's share.
+		f := newFixture(t)
+		f.hold()
+		cctx, cancel := context.WithCancel(ctx)
+		canceled := make([]*azor.Promise[string], 10)
+		for i := range canceled {
+			canceled[i] = f.s.Submit(cctx, "a", 1, func(context.Context) (string, error) { return "", nil })
+		}
+		cancel()
+		for _, p := range canceled {
+			p.Get(ctx)
+		}
+		f.submit(ctx, "b", 1, 3)
+		f.submit(ctx, "a", 1, 1)
+		got := f.run()
+		want := []string{"b1", "a1", "b2", "b3"}
+		if !slices.Equal(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+	t.Run("cancel refund middle", func(t *testing.T) {
+		// The tasks queued after a canceled one move up.
+		f := newFixture(t)
+		f.hold()
+		f.submit(ctx, "a", 1, 1)
+		cctx, cancel := context.WithCancel(ctx)
+		p := f.s.Submit(cctx, "a", 1, func(context.Context) (string, error) { return "", nil })
+		f.submit(ctx, "a", 1, 1)
+		f.submit(ctx, "b", 1, 2)
+		cancel()
+		p.Get(ctx)
+		got := f.run()
+		want := []string{"a1", "b1", "a2", "b2"}
+		if !slices.Equal(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+	t.Run("forget", func(t *testing.T) {
+		f := newFixture(t)
+		f.hold()
+		f.submit(ctx, "a", 1, 1)
+		if f.s.Forget("a") {
+			t.Error("forgot a tenant with queued tasks")
+		}
+		if f.s.Forget("gate") {
+			t.Error("forgot a tenant with running tasks")
+		}
+		f.run()
+		if !f.s.Forget("a") {
+			t.Error("did not forget an idle tenant")
+		}
+		if _, ok := f.s.AllStats()["a"]; ok {
+			t.Error("forgotten tenant still in stats")
+		}
+		if f.s.Forget("unknown") {
+			t.Error("forgot an unknown tenant")
+		}
+	})
+	t.Run("error and panic", func(t *testing.T) {
+		s := New[string, string](Config{Workers: 1})
+		defer s.Shutdown(azor.ShutdownDrain)
+		errBoom := errors.New("boom")
+		p1 := s.Submit(ctx, "a", 1, func(context.Context) (string, error) { return "", errBoom })
+		p2 := s.Submit(ctx, "a", 1, func(context.Context) (string, error) { panic("oops") })
+		if _, err := p1.Get(ctx); !errors.Is(err, errBoom) {
+			t.Errorf("got %v, want %v", err, errBoom)
+		}
+		if _, err := p2.Get(ctx); err == nil || err.Error() != "panic: oops" {
+			t.Errorf("got %v, want panic: oops", err)
+		}
+		if st := s.Stats("a"); st.Started != 2 {
+			t.Errorf("got %d started, want 2", st.Started)
+		}
+	})
+	t.Run("shutdown discard", func(t *testing.T) {
+		f := newFixture(t)
+		f.hold()
+		queued := f.submit(ctx, "a", 1, 2)
+		done := f.s.Shutdown(azor.ShutdownDiscard)
+		for _, p := range queued {
+			if _, err := p.Get(ctx); !errors.Is(err, azor.ErrClosed) {
+				t.Errorf("got %v, want ErrClosed", err)
+			}
+		}
+		late := f.submit(ctx, "b", 1, 1)
+		if _, err := late[0].Get(ctx); !errors.Is(err, azor.ErrClosed) {
+			t.Errorf("got %v, want ErrClosed", err)
+		}
+		if got := f.run(); len(got) != 0 {
+			t.Errorf("got %v, want no tasks run", got)
+		}
+		done.Get(ctx)
+	})
+	t.Run("nil function", func(t *testing.T) {
+		s := New[string, string](Config{Workers: 1})
+		defer s.Shutdown(azor.ShutdownDrain)
+		defer func() {
+			if recover() == nil {
+				t.Error("want panic")
+			}
+		}()
+		s.Submit(ctx, "a", 1, nil)
+	})
+}
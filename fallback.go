@@ -0,0 +1,70 @@
+package azor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// FallbackError is the error [Fallback] rejects with
+// when none of the functions succeed.
+type FallbackError struct {
+	// Errs lists the errors of the functions in the order
+	// they were called. If the context was canceled, the last
+	// error is the context's error.
+	Errs []error
+}
+
+// Error implements the error interface.
+func (e *FallbackError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "all %d fallbacks failed", len(e.Errs))
+	for i, err := range e.Errs {
+		fmt.Fprintf(&b, "; #%d: %v", i+1, err)
+	}
+	return b.String()
+}
+
+// Unwrap returns the errors of all the functions.
+func (e *FallbackError) Unwrap() []error {
+	return e.Errs
+}
+
+// Fallback calls the given functions one by one, moving to the next
+// function only if the previous one rejects. Returns a [Promise] that
+// resolves with the result of the first successful function.
+//
+// If all the functions fail, or the context is canceled while waiting
+// for a function, the promise rejects with a [*FallbackError] that
+// lists the errors in order.
+//
+// Panics if there are no functions or any of them is nil.
+func Fallback[T any](ctx context.Context, fns ...AsyncFunc[T]) *Promise[T] {
+	if len(fns) == 0 {
+		panic("azor: no functions")
+	}
+	for _, fn := range fns {
+		if fn == nil {
+			panic("azor: nil function")
+		}
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return Run(func() (T, error) {
+		var zero T
+		errs := make([]error, 0, len(fns))
+		for _, fn := range fns {
+			val, err := fn().Get(ctx)
+			if err == nil {
+				return val, nil
+			}
+			errs = append(errs, err)
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		return zero, &FallbackError{Errs: errs}
+	})
+}
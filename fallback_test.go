@@ -0,0 +1,121 @@
+package azor
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFallback(t *testing.T) {
+	errCache := errors.New("cache miss")
+	errDB := errors.New("db down")
+
+	t.Run("first success", func(t *testing.T) {
+		var calls atomic.Int32
+		p := Fallback(t.Context(),
+			Async(func() (string, error) { return "cache", nil }),
+			Async(func() (string, error) { calls.Add(1); return "db", nil }),
+		)
+
+		val, err := p.Get(t.Context())
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if val != "cache" {
+			t.Errorf("got val = %q, want cache", val)
+		}
+		if calls.Load() != 0 {
+			t.Error("should not call the next function")
+		}
+	})
+	t.Run("fallback", func(t *testing.T) {
+		p := Fallback(t.Context(),
+			Async(func() (string, error) { return "", errCache }),
+			Async(func() (string, error) { return "", errDB }),
+			Async(func() (string, error) { return "snapshot", nil }),
+		)
+
+		val, err := p.Get(t.Context())
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if val != "snapshot" {
+			t.Errorf("got val = %q, want snapshot", val)
+		}
+	})
+	t.Run("all failed", func(t *testing.T) {
+		p := Fallback(t.Context(),
+			Async(func() (string, error) { return "", errCache }),
+			Async(func() (string, error) { return "", errDB }),
+		)
+
+		_, err := p.Get(t.Context())
+		var ferr *FallbackError
+		if !errors.As(err, &ferr) {
+			t.Fatalf("got err = %v, want *FallbackError", err)
+		}
+		if len(ferr.Errs) != 2 || ferr.Errs[0] != errCache || ferr.Errs[1] != errDB {
+			t.Errorf("got errs = %v, want [%v %v]", ferr.Errs, errCache, errDB)
+		}
+		if !errors.Is(err, errCache) || !errors.Is(err, errDB) {
+			t.Errorf("got err = %v, want %v and %v", err, errCache, errDB)
+		}
+		want := "all 2 fallbacks failed; #1: cache miss; #2: db down"
+		if err.Error() != want {
+			t.Errorf("got err = %q, want %q", err, want)
+		}
+	})
+	t.Run("panic", func(t *testing.T) {
+		p := Fallback(t.Context(),
+			Async(func() (string, error) { panic("oops") }),
+			Async(func() (string, error) { return "db", nil }),
+		)
+
+		val, err := p.Get(t.Context())
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if val != "db" {
+			t.Errorf("got val = %q, want db", val)
+		}
+	})
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		var calls atomic.Int32
+		release := make(chan struct{})
+		defer close(release)
+		p := Fallback(ctx,
+			Async(func() (string, error) { <-release; return "", errCache }),
+			Async(func() (string, error) { calls.Add(1); return "db", nil }),
+		)
+
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+
+		_, err := p.Get(t.Context())
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got err = %v, want %v", err, context.Canceled)
+		}
+		if calls.Load() != 0 {
+			t.Error("should not call the next function")
+		}
+	})
+	t.Run("no functions", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("should panic for no functions")
+			}
+		}()
+		Fallback[int](t.Context())
+	})
+	t.Run("nil function", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("should panic for nil function")
+			}
+		}()
+		Fallback[int](t.Context(), nil)
+	})
+}
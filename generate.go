@@ -0,0 +1,146 @@
+package azor
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrClosed is the error that operations reject with when the object
+// they belong to is closed: for example, a generator's yield after the
+// consumer closes the sequence, [Go] after the scope has finished,
+// [Channel.Receive] on a closed and drained channel, or
+// [PriorityQueue.Submit] after the queue is shut down.
+var ErrClosed = errors.New("closed")
+
+// Generate returns a sequence of the values produced by the given
+// function, similar to an async generator (async function*) in JavaScript.
+//
+// The function (the producer) runs in a separate goroutine, which starts
+// on the first pull. The producer sends values to the consumer with yield.
+// The promise returned by yield resolves when the consumer has received
+// the value and pulls the next one, so the producer never gets ahead of
+// the consumer. The producer should wait for each promise before
+// producing the next value:
+//
+//	seq := azor.Generate(func(ctx context.Context, yield func(int) *azor.Promise[struct{}]) error {
+//	    for i := 0; ; i++ {
+//	        if _, err := yield(i).Get(ctx); err != nil {
+//	            return err
+//	        }
+//	    }
+//	})
+//
+// When the producer returns, the sequence ends. If the producer returns
+// an error (or panics), the consumer receives it after the last value.
+//
+// Closing the sequence cancels the producer's context, and the pending
+// yield rejects with [ErrClosed]. Close does not wait for the producer
+// to return.
+//
+// Panics if the function is nil.
+func Generate[T any](fn func(ctx context.Context, yield func(T) *Promise[struct{}]) error) *AsyncSeq[T] {
+	if fn == nil {
+		panic("azor: nil function")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	g := &generator[T]{
+		fn:     fn,
+		ctx:    ctx,
+		cancel: cancel,
+		items:  make(chan T),
+		pulls:  make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	return newSeq(g.next, g.cancel)
+}
+
+// generator hands over the values from the producer
+// to the consumer, one value per pull.
+type generator[T any] struct {
+	fn     func(context.Context, func(T) *Promise[struct{}]) error
+	ctx    context.Context // canceled when the sequence is closed
+	cancel context.CancelFunc
+
+	items chan T        // producer -> consumer
+	pulls chan struct{} // consumer -> producer: resume after yield
+	done  chan struct{} // closed when the producer returns
+	err   error         // the producer's error, set before done is closed
+
+	// Consumer state (pulls are serialized by AsyncSeq).
+	started  bool // the producer is started
+	resumed  bool // the producer is resumed but hasn't yielded yet
+	reported bool // the producer's error is reported
+
+	// Producer state.
+	yieldMu sync.Mutex // serializes yields
+}
+
+// next pulls the next value from the producer.
+func (g *generator[T]) next(ctx context.Context) (T, error) {
+	var zero T
+
+	// Start or resume the producer, unless it's already
+	// working on the value for the previous (canceled) pull.
+	if !g.started {
+		g.started = true
+		g.resumed = true
+		go g.run()
+	} else if !g.resumed {
+		select {
+		case g.pulls <- struct{}{}:
+			g.resumed = true
+		case <-g.done:
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+	}
+
+	// Wait for the producer to yield a value or return.
+	select {
+	case val := <-g.items:
+		g.resumed = false
+		return val, nil
+	case <-g.done:
+		if g.err != nil && !g.reported {
+			g.reported = true
+			return zero, g.err
+		}
+		return zero, ErrEnd
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// yield sends the value to the consumer and returns a promise
+// that resolves when the consumer pulls the next value.
+func (g *generator[T]) yield(val T) *Promise[struct{}] {
+	return Run(func() (struct{}, error) {
+		g.yieldMu.Lock()
+		defer g.yieldMu.Unlock()
+
+		select {
+		case g.items <- val:
+		case <-g.ctx.Done():
+			return struct{}{}, ErrClosed
+		}
+
+		select {
+		case <-g.pulls:
+			return struct{}{}, nil
+		case <-g.ctx.Done():
+			return struct{}{}, ErrClosed
+		}
+	})
+}
+
+// run runs the producer.
+func (g *generator[T]) run() {
+	_, err := try(func() (struct{}, error) {
+		return struct{}{}, g.fn(g.ctx, g.yield)
+	})
+	g.err = err
+	// Release the yields the producer didn't wait for.
+	g.cancel()
+	close(g.done)
+}
@@ -0,0 +1,183 @@
+package azor
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Run("values", func(t *testing.T) {
+		s := Generate(func(ctx context.Context, yield func(int) *Promise[struct{}]) error {
+			for i := range 3 {
+				if _, err := yield(i).Get(ctx); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+		got, err := collect(t, s)
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if !slices.Equal(got, []int{0, 1, 2}) {
+			t.Errorf("got %v, want [0 1 2]", got)
+		}
+	})
+	t.Run("lazy start", func(t *testing.T) {
+		var started atomic.Bool
+		s := Generate(func(ctx context.Context, yield func(int) *Promise[struct{}]) error {
+			started.Store(true)
+			return nil
+		})
+		defer s.Close()
+
+		time.Sleep(10 * time.Millisecond)
+		if started.Load() {
+			t.Error("should not start before the first pull")
+		}
+	})
+	t.Run("backpressure", func(t *testing.T) {
+		var produced atomic.Int32
+		s := Generate(func(ctx context.Context, yield func(int) *Promise[struct{}]) error {
+			for i := 0; ; i++ {
+				produced.Add(1)
+				if _, err := yield(i).Get(ctx); err != nil {
+					return err
+				}
+			}
+		})
+		defer s.Close()
+
+		for want := range 3 {
+			val, err := s.Next(t.Context()).Get(t.Context())
+			if err != nil {
+				t.Fatalf("got err = %v, want nil", err)
+			}
+			if val != want {
+				t.Errorf("got val = %d, want %d", val, want)
+			}
+			time.Sleep(10 * time.Millisecond)
+			if n := produced.Load(); n != int32(want+1) {
+				t.Errorf("got %d produced, want %d", n, want+1)
+			}
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		s := Generate(func(ctx context.Context, yield func(int) *Promise[struct{}]) error {
+			if _, err := yield(1).Get(ctx); err != nil {
+				return err
+			}
+			return errDummy
+		})
+
+		got, err := collect(t, s)
+		if !errors.Is(err, errDummy) {
+			t.Errorf("got err = %v, want %v", err, errDummy)
+		}
+		if !slices.Equal(got, []int{1}) {
+			t.Errorf("got %v, want [1]", got)
+		}
+	})
+	t.Run("error then end", func(t *testing.T) {
+		s := Generate(func(ctx context.Context, yield func(int) *Promise[struct{}]) error {
+			return errDummy
+		})
+
+		_, err := s.Next(t.Context()).Get(t.Context())
+		if !errors.Is(err, errDummy) {
+			t.Errorf("got err = %v, want %v", err, errDummy)
+		}
+		_, err = s.Next(t.Context()).Get(t.Context())
+		if !errors.Is(err, ErrEnd) {
+			t.Errorf("got err = %v, want %v", err, ErrEnd)
+		}
+	})
+	t.Run("panic", func(t *testing.T) {
+		s := Generate(func(ctx context.Context, yield func(int) *Promise[struct{}]) error {
+			panic("oops")
+		})
+
+		_, err := s.Next(t.Context()).Get(t.Context())
+		want := "panic: oops"
+		if err == nil || err.Error() != want {
+			t.Errorf("got err = %q, want %q", err, want)
+		}
+	})
+	t.Run("close", func(t *testing.T) {
+		yieldErr := make(chan error, 1)
+		s := Generate(func(ctx context.Context, yield func(int) *Promise[struct{}]) error {
+			for i := 0; ; i++ {
+				if _, err := yield(i).Get(context.Background()); err != nil {
+					yieldErr <- err
+					return err
+				}
+			}
+		})
+
+		for val, err := range s.All(t.Context()) {
+			if err != nil || val != 0 {
+				t.Errorf("got %v, %v, want 0, nil", val, err)
+			}
+			break
+		}
+
+		select {
+		case err := <-yieldErr:
+			if !errors.Is(err, ErrClosed) {
+				t.Errorf("got err = %v, want %v", err, ErrClosed)
+			}
+		case <-time.After(time.Second):
+			t.Error("yield should reject after close")
+		}
+	})
+	t.Run("canceled pull", func(t *testing.T) {
+		release := make(chan struct{})
+		s := Generate(func(ctx context.Context, yield func(int) *Promise[struct{}]) error {
+			for i := 0; ; i++ {
+				if i == 1 {
+					<-release
+				}
+				if _, err := yield(i).Get(ctx); err != nil {
+					return err
+				}
+			}
+		})
+		defer s.Close()
+
+		if val, _ := s.Next(t.Context()).Get(t.Context()); val != 0 {
+			t.Fatalf("got val = %d, want 0", val)
+		}
+
+		// The producer is slow to produce the next value,
+		// so the pull times out.
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+		_, err := s.Next(ctx).Get(t.Context())
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got err = %v, want %v", err, context.DeadlineExceeded)
+		}
+
+		// The next pull receives the value.
+		close(release)
+		val, err := s.Next(t.Context()).Get(t.Context())
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if val != 1 {
+			t.Errorf("got val = %d, want 1", val)
+		}
+	})
+	t.Run("nil function", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("should panic for nil function")
+			}
+		}()
+		Generate[int](nil)
+	})
+}
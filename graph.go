@@ -0,0 +1,260 @@
+package azor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrCycle is the error that [Graph.Validate] and [Graph.Run]
+// return when the graph's dependencies form a cycle.
+var ErrCycle = errors.New("dependency cycle")
+
+// ErrDependency is the error that a graph node rejects with
+// when one of its dependencies fails, without calling its function.
+var ErrDependency = errors.New("dependency failed")
+
+// Graph is a set of named tasks with dependencies between them
+// (a directed acyclic graph). [Graph.Run] runs each task after all
+// its dependencies have finished, passing their results to the
+// task's function. Independent tasks run concurrently.
+//
+// Build the graph with [Graph.Add], then run it any number of times.
+// Graph is safe for concurrent use.
+type Graph[T any] struct {
+	mu    sync.Mutex
+	nodes map[string]*graphNode[T]
+	names []string // in the order they were added
+	err   error    // first error from Add
+}
+
+// graphNode is a task in a graph.
+type graphNode[T any] struct {
+	deps []string
+	fn   func(ctx context.Context, deps map[string]T) (T, error)
+}
+
+// NewGraph creates a new empty graph.
+func NewGraph[T any]() *Graph[T] {
+	return &Graph[T]{nodes: make(map[string]*graphNode[T])}
+}
+
+// Add adds a task with the given name and dependencies (the names
+// of other tasks). The task's function receives the results of the
+// dependencies, keyed by name. The dependencies don't have to be
+// added before the task that uses them.
+//
+// Adding a task with a name that's already in the graph is an error,
+// reported by [Graph.Validate] and [Graph.Run].
+//
+// Panics if the function is nil.
+func (g *Graph[T]) Add(name string, deps []string, fn func(ctx context.Context, deps map[string]T) (T, error)) {
+	if fn == nil {
+		panic("azor: nil function")
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.nodes[name]; ok {
+		if g.err == nil {
+			g.err = fmt.Errorf("duplicate node %q", name)
+		}
+		return
+	}
+	g.nodes[name] = &graphNode[T]{deps: slices.Clone(deps), fn: fn}
+	g.names = append(g.names, name)
+}
+
+// Validate checks that the graph is valid: there are no duplicate
+// names, all the dependencies exist, and there are no cycles
+// (an error wrapping [ErrCycle] describes the cycle).
+func (g *Graph[T]) Validate() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.validate()
+}
+
+// validate checks that the graph is valid.
+// Must be called with the mutex held.
+func (g *Graph[T]) validate() error {
+	if g.err != nil {
+		return g.err
+	}
+	for _, name := range g.names {
+		for _, dep := range g.nodes[name].deps {
+			if _, ok := g.nodes[dep]; !ok {
+				return fmt.Errorf("node %q: unknown dependency %q", name, dep)
+			}
+		}
+	}
+
+	// Depth-first search, looking for a dependency
+	// on a node that's still being visited.
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(g.names))
+	var path []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			start := slices.Index(path, name)
+			cycle := append(slices.Clone(path[start:]), name)
+			return fmt.Errorf("%w: %s", ErrCycle, strings.Join(cycle, " -> "))
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range g.nodes[name].deps {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+	for _, name := range g.names {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GraphRun is a run of a [Graph], with a [Promise] for each task
+// and one for the whole graph.
+type GraphRun[T any] struct {
+	nodes map[string]*Promise[T]
+	all   *Promise[map[string]T]
+}
+
+// Node returns the [Promise] of the task with the given name,
+// or nil if there is no such task.
+func (r *GraphRun[T]) Node(name string) *Promise[T] {
+	return r.nodes[name]
+}
+
+// All returns a [Promise] that resolves with the results of all
+// the tasks, keyed by name, when all of them succeed. If any task
+// fails, the promise rejects (after all the tasks have settled) with
+// the errors of the failed tasks joined together, not including the
+// tasks rejected because of a failed dependency. If the context is
+// canceled, the promise rejects with the context's error.
+func (r *GraphRun[T]) All() *Promise[map[string]T] {
+	return r.all
+}
+
+// Run validates the graph and starts running its tasks, with at most
+// parallelism functions running at a time (no limit if parallelism
+// is zero or negative). Returns an error if the graph is not valid
+// (see [Graph.Validate]), without running any tasks.
+//
+// Each task runs after all its dependencies succeed. If a dependency
+// fails, the task and all its dependents reject with an error wrapping
+// [ErrDependency] (and the dependency's error) without calling their
+// functions. Other tasks are not affected. If the context is canceled,
+// the tasks that have not started reject with the context's error,
+// and the running ones receive the canceled context.
+func (g *Graph[T]) Run(ctx context.Context, parallelism int) (*GraphRun[T], error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.validate(); err != nil {
+		return nil, err
+	}
+
+	var sem chan struct{}
+	if parallelism > 0 {
+		sem = make(chan struct{}, parallelism)
+	}
+
+	// Create the promises first, so that each task
+	// can wait for its dependencies' promises.
+	type pending struct {
+		resolve func(T)
+		reject  func(error)
+	}
+	run := &GraphRun[T]{nodes: make(map[string]*Promise[T], len(g.names))}
+	settlers := make(map[string]pending, len(g.names))
+	for _, name := range g.names {
+		p, resolve, reject := WithResolvers[T]()
+		run.nodes[name] = p
+		settlers[name] = pending{resolve, reject}
+	}
+
+	for _, name := range g.names {
+		node := g.nodes[name]
+		settle := settlers[name]
+		go func() {
+			val, err := runNode(ctx, node, run.nodes, sem)
+			if err != nil {
+				settle.reject(err)
+			} else {
+				settle.resolve(val)
+			}
+		}()
+	}
+
+	names := slices.Clone(g.names)
+	run.all = Run(func() (map[string]T, error) {
+		vals := make(map[string]T, len(names))
+		var errs []error
+		for _, name := range names {
+			val, err := run.nodes[name].Get(context.Background())
+			if err != nil {
+				if !errors.Is(err, ErrDependency) {
+					errs = append(errs, fmt.Errorf("node %q: %w", name, err))
+				}
+				continue
+			}
+			vals[name] = val
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, context.Cause(ctx)
+		}
+		if len(errs) > 0 {
+			return nil, errors.Join(errs...)
+		}
+		return vals, nil
+	})
+	return run, nil
+}
+
+// runNode waits for the node's dependencies, then calls its function
+// (limited by the semaphore, if not nil) with their results.
+func runNode[T any](ctx context.Context, node *graphNode[T], promises map[string]*Promise[T], sem chan struct{}) (T, error) {
+	var zero T
+	deps := make(map[string]T, len(node.deps))
+	for _, dep := range node.deps {
+		val, err := promises[dep].Get(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return zero, context.Cause(ctx)
+			}
+			return zero, fmt.Errorf("%w: %q: %w", ErrDependency, dep, err)
+		}
+		deps[dep] = val
+	}
+
+	if sem != nil {
+		select {
+		case sem <- struct{}{}:
+			defer func() { <-sem }()
+		case <-ctx.Done():
+			return zero, context.Cause(ctx)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return zero, context.Cause(ctx)
+	}
+	return try(func() (T, error) { return node.fn(ctx, deps) })
+}
@@ -0,0 +1,222 @@
+package azor
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// sum returns a graph function that adds the given value
+// to the sum of its dependencies' results.
+func sum(val int) func(context.Context, map[string]int) (int, error) {
+	return func(_ context.Context, deps map[string]int) (int, error) {
+		total := val
+		for _, v := range deps {
+			total += v
+		}
+		return total, nil
+	}
+}
+
+func TestGraph(t *testing.T) {
+	t.Run("run", func(t *testing.T) {
+		g := NewGraph[int]()
+		g.Add("d", []string{"b", "c"}, sum(1000))
+		g.Add("a", nil, sum(1))
+		g.Add("b", []string{"a"}, sum(10))
+		g.Add("c", []string{"a"}, sum(100))
+		run, err := g.Run(t.Context(), 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if val, err := run.Node("b").Get(t.Context()); val != 11 || err != nil {
+			t.Errorf("b: got %d, %v; want 11, nil", val, err)
+		}
+		got, err := run.All().Get(t.Context())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := map[string]int{"a": 1, "b": 11, "c": 101, "d": 1112}
+		if !maps.Equal(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+		if run.Node("unknown") != nil {
+			t.Error("want nil for unknown node")
+		}
+	})
+	t.Run("order", func(t *testing.T) {
+		g := NewGraph[int]()
+		var mu sync.Mutex
+		var order []string
+		record := func(name string) func(context.Context, map[string]int) (int, error) {
+			return func(context.Context, map[string]int) (int, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				order = append(order, name)
+				return 0, nil
+			}
+		}
+		g.Add("c", []string{"b"}, record("c"))
+		g.Add("b", []string{"a"}, record("b"))
+		g.Add("a", nil, record("a"))
+		run, _ := g.Run(t.Context(), 0)
+		run.All().Get(t.Context())
+		if len(order) != 3 || order[0] != "a" || order[1] != "b" || order[2] != "c" {
+			t.Errorf("got %v, want [a b c]", order)
+		}
+	})
+	t.Run("parallelism", func(t *testing.T) {
+		g := NewGraph[int]()
+		var running, maxRunning atomic.Int32
+		fn := func(context.Context, map[string]int) (int, error) {
+			n := running.Add(1)
+			for {
+				cur := maxRunning.Load()
+				if n <= cur || maxRunning.CompareAndSwap(cur, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+			return 0, nil
+		}
+		for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+			g.Add(name, nil, fn)
+		}
+		run, _ := g.Run(t.Context(), 2)
+		run.All().Get(t.Context())
+		if n := maxRunning.Load(); n != 2 {
+			t.Errorf("got %d max running, want 2", n)
+		}
+	})
+	t.Run("failure", func(t *testing.T) {
+		g := NewGraph[int]()
+		var calls atomic.Int32
+		g.Add("a", nil, func(context.Context, map[string]int) (int, error) {
+			return 0, errDummy
+		})
+		g.Add("b", []string{"a"}, func(context.Context, map[string]int) (int, error) {
+			calls.Add(1)
+			return 0, nil
+		})
+		g.Add("c", []string{"b"}, sum(1))
+		g.Add("x", nil, sum(42))
+		run, _ := g.Run(t.Context(), 0)
+
+		_, err := run.Node("c").Get(t.Context())
+		if !errors.Is(err, ErrDependency) || !errors.Is(err, errDummy) {
+			t.Errorf("c: got %v, want ErrDependency and %v", err, errDummy)
+		}
+		if val, err := run.Node("x").Get(t.Context()); val != 42 || err != nil {
+			t.Errorf("x: got %d, %v; want 42, nil", val, err)
+		}
+		_, err = run.All().Get(t.Context())
+		if !errors.Is(err, errDummy) || errors.Is(err, ErrDependency) {
+			t.Errorf("all: got %v, want %v only", err, errDummy)
+		}
+		if n := calls.Load(); n != 0 {
+			t.Errorf("got %d calls of b, want 0", n)
+		}
+	})
+	t.Run("panic", func(t *testing.T) {
+		g := NewGraph[int]()
+		g.Add("a", nil, func(context.Context, map[string]int) (int, error) {
+			panic("boom")
+		})
+		run, _ := g.Run(t.Context(), 0)
+		if _, err := run.All().Get(t.Context()); err == nil {
+			t.Error("want error")
+		}
+	})
+	t.Run("cancel", func(t *testing.T) {
+		g := NewGraph[int]()
+		g.Add("a", nil, func(ctx context.Context, _ map[string]int) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		})
+		g.Add("b", []string{"a"}, sum(1))
+		ctx, cancel := context.WithCancel(t.Context())
+		run, _ := g.Run(ctx, 0)
+		cancel()
+		if _, err := run.Node("b").Get(t.Context()); !errors.Is(err, context.Canceled) {
+			t.Errorf("b: got %v, want context.Canceled", err)
+		}
+		if _, err := run.All().Get(t.Context()); !errors.Is(err, context.Canceled) {
+			t.Errorf("all: got %v, want context.Canceled", err)
+		}
+	})
+	t.Run("rerun", func(t *testing.T) {
+		g := NewGraph[int]()
+		var calls atomic.Int32
+		g.Add("a", nil, func(context.Context, map[string]int) (int, error) {
+			return int(calls.Add(1)), nil
+		})
+		for want := 1; want <= 2; want++ {
+			run, _ := g.Run(t.Context(), 0)
+			if val, _ := run.Node("a").Get(t.Context()); val != want {
+				t.Errorf("got %d, want %d", val, want)
+			}
+		}
+	})
+	t.Run("nil function", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("want panic")
+			}
+		}()
+		NewGraph[int]().Add("a", nil, nil)
+	})
+}
+
+func TestGraph_Validate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		g := NewGraph[int]()
+		g.Add("a", nil, sum(1))
+		g.Add("b", []string{"a"}, sum(1))
+		if err := g.Validate(); err != nil {
+			t.Errorf("got %v, want nil", err)
+		}
+	})
+	t.Run("cycle", func(t *testing.T) {
+		g := NewGraph[int]()
+		g.Add("a", []string{"c"}, sum(1))
+		g.Add("b", []string{"a"}, sum(1))
+		g.Add("c", []string{"b"}, sum(1))
+		err := g.Validate()
+		if !errors.Is(err, ErrCycle) {
+			t.Fatalf("got %v, want ErrCycle", err)
+		}
+		if want := "dependency cycle: a -> c -> b -> a"; err.Error() != want {
+			t.Errorf("got %q, want %q", err, want)
+		}
+		if _, err := g.Run(t.Context(), 0); !errors.Is(err, ErrCycle) {
+			t.Errorf("run: got %v, want ErrCycle", err)
+		}
+	})
+	t.Run("self", func(t *testing.T) {
+		g := NewGraph[int]()
+		g.Add("a", []string{"a"}, sum(1))
+		if err := g.Validate(); !errors.Is(err, ErrCycle) {
+			t.Errorf("got %v, want ErrCycle", err)
+		}
+	})
+	t.Run("unknown dependency", func(t *testing.T) {
+		g := NewGraph[int]()
+		g.Add("a", []string{"b"}, sum(1))
+		if err := g.Validate(); err == nil {
+			t.Error("want error")
+		}
+	})
+	t.Run("duplicate", func(t *testing.T) {
+		g := NewGraph[int]()
+		g.Add("a", nil, sum(1))
+		g.Add("a", nil, sum(2))
+		if err := g.Validate(); err == nil {
+			t.Error("want error")
+		}
+	})
+}
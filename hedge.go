@@ -0,0 +1,80 @@
+package azor
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Hedge calls the given function asynchronously and, if there is
+// no result after the delay, calls it again concurrently, up to
+// maxAttempts calls in total. Returns a [Promise] that resolves
+// with the result of the first successful call.
+//
+// Hedging cuts the tail latency: a single slow call no longer
+// delays the result, at the cost of some extra calls.
+//
+// Once a call succeeds, the other calls are canceled through their
+// context. If a call fails and no other calls are in flight, the next
+// call starts right away without waiting for the delay. If all calls
+// fail, the promise rejects with all their errors joined together.
+// If the context is canceled, the promise rejects with the context's error.
+//
+// Panics if the function is nil.
+func Hedge[T any](ctx context.Context, fn func(context.Context) (T, error), delay time.Duration, maxAttempts int) *Promise[T] {
+	if fn == nil {
+		panic("azor: nil function")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	maxAttempts = max(maxAttempts, 1)
+
+	return Run(func() (T, error) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		// The channel is buffered so that the calls
+		// finishing after the winner don't block.
+		results := make(chan Result[T], maxAttempts)
+		launched := 0
+		launch := func() {
+			launched++
+			go func() {
+				val, err := try(func() (T, error) { return fn(ctx) })
+				results <- Result[T]{val, err}
+			}()
+		}
+
+		launch()
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		var zero T
+		var errs []error
+		for {
+			select {
+			case res := <-results:
+				if res.Err == nil {
+					return res.Val, nil
+				}
+				errs = append(errs, res.Err)
+				if len(errs) == maxAttempts {
+					return zero, errors.Join(errs...)
+				}
+				if len(errs) == launched {
+					// Nothing else in flight, try again right away.
+					launch()
+					timer.Reset(delay)
+				}
+			case <-timer.C:
+				if launched < maxAttempts {
+					launch()
+					timer.Reset(delay)
+				}
+			case <-ctx.Done():
+				return zero, ctx.Err()
+			}
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package azor
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHedge(t *testing.T) {
+	t.Run("fast first", func(t *testing.T) {
+		var calls atomic.Int32
+		p := Hedge(t.Context(), func(ctx context.Context) (int, error) {
+			calls.Add(1)
+			return 42, nil
+		}, 50*time.Millisecond, 3)
+
+		val, err := p.Get(t.Context())
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if val != 42 {
+			t.Errorf("got val = %d, want 42", val)
+		}
+		if calls.Load() != 1 {
+			t.Errorf("got %d calls, want 1", calls.Load())
+		}
+	})
+	t.Run("slow first", func(t *testing.T) {
+		var calls atomic.Int32
+		canceled := make(chan struct{})
+		p := Hedge(t.Context(), func(ctx context.Context) (int, error) {
+			n := calls.Add(1)
+			if n == 1 {
+				// The first call hangs until canceled.
+				<-ctx.Done()
+				close(canceled)
+				return 0, ctx.Err()
+			}
+			return int(n), nil
+		}, 10*time.Millisecond, 3)
+
+		val, err := p.Get(t.Context())
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if val != 2 {
+			t.Errorf("got val = %d, want 2", val)
+		}
+
+		select {
+		case <-canceled:
+		case <-time.After(time.Second):
+			t.Error("the slow call should be canceled")
+		}
+	})
+	t.Run("max attempts", func(t *testing.T) {
+		var calls atomic.Int32
+		release := make(chan struct{})
+		p := Hedge(t.Context(), func(ctx context.Context) (int, error) {
+			calls.Add(1)
+			<-release
+			return 42, nil
+		}, time.Millisecond, 3)
+
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+
+		if _, err := p.Get(t.Context()); err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if calls.Load() != 3 {
+			t.Errorf("got %d calls, want 3", calls.Load())
+		}
+	})
+	t.Run("retry on failure", func(t *testing.T) {
+		var calls atomic.Int32
+		start := time.Now()
+		p := Hedge(t.Context(), func(ctx context.Context) (int, error) {
+			if calls.Add(1) == 1 {
+				return 0, errDummy
+			}
+			return 42, nil
+		}, time.Second, 2)
+
+		val, err := p.Get(t.Context())
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if val != 42 {
+			t.Errorf("got val = %d, want 42", val)
+		}
+		if time.Since(start) > 500*time.Millisecond {
+			t.Error("should not wait for the delay after a failure")
+		}
+	})
+	t.Run("all failed", func(t *testing.T) {
+		errOther := errors.New("other")
+		var calls atomic.Int32
+		p := Hedge(t.Context(), func(ctx context.Context) (int, error) {
+			if calls.Add(1) == 1 {
+				return 0, errDummy
+			}
+			return 0, errOther
+		}, time.Millisecond, 2)
+
+		_, err := p.Get(t.Context())
+		if !errors.Is(err, errDummy) || !errors.Is(err, errOther) {
+			t.Errorf("got err = %v, want %v and %v", err, errDummy, errOther)
+		}
+	})
+	t.Run("panic", func(t *testing.T) {
+		p := Hedge(t.Context(), func(ctx context.Context) (int, error) {
+			panic("oops")
+		}, time.Millisecond, 1)
+
+		_, err := p.Get(t.Context())
+		want := "panic: oops"
+		if err == nil || err.Error() != want {
+			t.Errorf("got err = %q, want %q", err, want)
+		}
+	})
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		p := Hedge(ctx, func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}, time.Millisecond, 2)
+
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+
+		_, err := p.Get(t.Context())
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got err = %v, want %v", err, context.Canceled)
+		}
+	})
+	t.Run("nil function", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("should panic for nil function")
+			}
+		}()
+		Hedge[int](t.Context(), nil, time.Millisecond, 1)
+	})
+}
@@ -0,0 +1,24 @@
+// Package safe calls user functions, turning their panics into errors,
+// for the packages of this module that run functions on their own
+// goroutines.
+package safe
+
+import "fmt"
+
+// Call calls the given function and turns a panic into an error:
+// an error value is returned as is, and any other value
+// is wrapped in a "panic: <value>" error.
+func Call[T any](fn func() (T, error)) (val T, err error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		if e, ok := r.(error); ok {
+			err = e
+		} else {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn()
+}
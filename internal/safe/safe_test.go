@@ -0,0 +1,28 @@
+package safe
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCall(t *testing.T) {
+	errDummy := errors.New("dummy")
+	t.Run("result", func(t *testing.T) {
+		val, err := Call(func() (int, error) { return 42, errDummy })
+		if val != 42 || err != errDummy {
+			t.Errorf("got %v, %v; want 42, %v", val, err, errDummy)
+		}
+	})
+	t.Run("panic error", func(t *testing.T) {
+		_, err := Call(func() (int, error) { panic(errDummy) })
+		if err != errDummy {
+			t.Errorf("got %v, want %v", err, errDummy)
+		}
+	})
+	t.Run("panic value", func(t *testing.T) {
+		_, err := Call(func() (int, error) { panic("oops") })
+		if err == nil || err.Error() != "panic: oops" {
+			t.Errorf("got %v, want panic: oops", err)
+		}
+	})
+}
@@ -0,0 +1,205 @@
+package azor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is the error that [Loader.Load] rejects with
+// when the batch function does not return a value for the key.
+var ErrNotFound = errors.New("not found")
+
+// BatchErrors is an error that a batch function returns
+// to fail some of the keys in the batch, but not the others.
+// It maps each failed key to its error. The keys that are not
+// in the map get their values from the batch result as usual.
+type BatchErrors[K comparable] map[K]error
+
+// Error implements the error interface.
+func (e BatchErrors[K]) Error() string {
+	return fmt.Sprintf("batch failed for %d keys", len(e))
+}
+
+// LoaderConfig configures a [Loader].
+type LoaderConfig struct {
+	// Wait is how long the loader collects keys before calling
+	// the batch function. Zero means the loader collects the keys
+	// requested until the dispatching goroutine gets to run
+	// (about one scheduler tick).
+	Wait time.Duration
+
+	// MaxBatch is the maximum number of keys in a batch.
+	// When a batch is full, the loader calls the batch function
+	// right away, and starts a new batch. Zero means no limit.
+	MaxBatch int
+
+	// DisableCache disables caching of the loaded values.
+	// By default, the loader remembers each loaded value for
+	// its lifetime, and does not load the same key twice.
+	// Failed loads are never cached.
+	DisableCache bool
+}
+
+// Loader coalesces individual loads into batches, similar to
+// DataLoader in JavaScript. Instead of loading each key separately
+// (the N+1 queries problem), the loader collects the keys requested
+// within a short window and loads them with a single call to the
+// batch function.
+//
+// A loader is usually created per request, so that its cache
+// does not outlive the request. Loader is safe for concurrent use.
+type Loader[K comparable, V any] struct {
+	ctx   context.Context
+	batch func(context.Context, []K) (map[K]V, error)
+	cfg   LoaderConfig
+
+	mu      sync.Mutex
+	cache   map[K]*Promise[V]
+	pending *loaderBatch[K, V] // batch collecting keys
+}
+
+// loaderBatch is a batch of keys to load.
+type loaderBatch[K comparable, V any] struct {
+	keys  []K
+	calls map[K]*loaderCall[V]
+	timer *time.Timer
+	sent  bool // true when the batch is dispatched
+}
+
+// loaderCall is a pending load of a single key.
+type loaderCall[V any] struct {
+	p       *Promise[V]
+	resolve func(V)
+	reject  func(error)
+}
+
+// NewLoader creates a new loader that loads keys with the given
+// batch function. The batch function receives the given context
+// and the keys to load (without duplicates), and returns the values
+// by key. To fail individual keys, it returns [BatchErrors].
+// Any other error fails all the keys in the batch.
+//
+// Panics if the function is nil.
+func NewLoader[K comparable, V any](ctx context.Context, batch func(context.Context, []K) (map[K]V, error), cfg LoaderConfig) *Loader[K, V] {
+	if batch == nil {
+		panic("azor: nil function")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &Loader[K, V]{
+		ctx:   ctx,
+		batch: batch,
+		cfg:   cfg,
+		cache: make(map[K]*Promise[V]),
+	}
+}
+
+// Load returns a [Promise] that resolves with the value for the key.
+// The key is loaded together with the other keys requested within
+// the same window. If the key is already loaded (or being loaded),
+// returns the same promise as before.
+//
+// If the batch function fails, the promise rejects with its error
+// (or the key's error from [BatchErrors]). If the batch function
+// does not return a value for the key, the promise rejects
+// with [ErrNotFound].
+func (l *Loader[K, V]) Load(key K) *Promise[V] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if p, ok := l.cache[key]; ok {
+		return p
+	}
+
+	b := l.pending
+	if b == nil {
+		b = &loaderBatch[K, V]{calls: make(map[K]*loaderCall[V])}
+		b.timer = time.AfterFunc(l.cfg.Wait, func() { l.flush(b) })
+		l.pending = b
+	}
+	if c, ok := b.calls[key]; ok {
+		return c.p
+	}
+
+	c := &loaderCall[V]{}
+	c.p, c.resolve, c.reject = WithResolvers[V]()
+	b.keys = append(b.keys, key)
+	b.calls[key] = c
+	if !l.cfg.DisableCache {
+		l.cache[key] = c.p
+	}
+
+	if l.cfg.MaxBatch > 0 && len(b.keys) >= l.cfg.MaxBatch {
+		// The batch is full, dispatch it right away.
+		b.timer.Stop()
+		b.sent = true
+		l.pending = nil
+		go l.run(b)
+	}
+	return c.p
+}
+
+// Clear removes the key from the cache,
+// so the next Load loads it again.
+func (l *Loader[K, V]) Clear(key K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.cache, key)
+}
+
+// flush dispatches the batch when its window is over,
+// unless it is already dispatched.
+func (l *Loader[K, V]) flush(b *loaderBatch[K, V]) {
+	l.mu.Lock()
+	if b.sent {
+		l.mu.Unlock()
+		return
+	}
+	b.sent = true
+	if l.pending == b {
+		l.pending = nil
+	}
+	l.mu.Unlock()
+	l.run(b)
+}
+
+// run calls the batch function and settles
+// the promises for the keys in the batch.
+func (l *Loader[K, V]) run(b *loaderBatch[K, V]) {
+	vals, err := try(func() (map[K]V, error) { return l.batch(l.ctx, b.keys) })
+	var keyErrs BatchErrors[K]
+	if errors.As(err, &keyErrs) {
+		err = nil
+	}
+
+	for _, key := range b.keys {
+		c := b.calls[key]
+		kerr := err
+		if kerr == nil {
+			kerr = keyErrs[key]
+		}
+		val, ok := vals[key]
+		if kerr == nil && !ok {
+			kerr = fmt.Errorf("key %v: %w", key, ErrNotFound)
+		}
+		if kerr != nil {
+			l.uncache(key, c.p)
+			c.reject(kerr)
+			continue
+		}
+		c.resolve(val)
+	}
+}
+
+// uncache removes the failed promise from the cache.
+func (l *Loader[K, V]) uncache(key K, p *Promise[V]) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.cache[key] == p {
+		delete(l.cache, key)
+	}
+}
@@ -0,0 +1,176 @@
+package azor
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// batchRecorder returns a batch function that records the batches
+// and returns the key multiplied by 10 for each key.
+func batchRecorder() (func(context.Context, []int) (map[int]int, error), func() [][]int) {
+	var mu sync.Mutex
+	var batches [][]int
+	fn := func(ctx context.Context, keys []int) (map[int]int, error) {
+		mu.Lock()
+		batches = append(batches, slices.Clone(keys))
+		mu.Unlock()
+		vals := make(map[int]int, len(keys))
+		for _, key := range keys {
+			vals[key] = key * 10
+		}
+		return vals, nil
+	}
+	get := func() [][]int {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(batches)
+	}
+	return fn, get
+}
+
+func TestLoader(t *testing.T) {
+	t.Run("batch", func(t *testing.T) {
+		fn, batches := batchRecorder()
+		l := NewLoader(t.Context(), fn, LoaderConfig{Wait: 10 * time.Millisecond})
+		var ps []*Promise[int]
+		for i := range 5 {
+			ps = append(ps, l.Load(i))
+		}
+		for i, p := range ps {
+			val, err := p.Get(t.Context())
+			if err != nil || val != i*10 {
+				t.Errorf("key %d: got %v, %v; want %v, nil", i, val, err, i*10)
+			}
+		}
+		if got := batches(); len(got) != 1 || !slices.Equal(got[0], []int{0, 1, 2, 3, 4}) {
+			t.Errorf("got batches %v, want [[0 1 2 3 4]]", got)
+		}
+	})
+	t.Run("tick", func(t *testing.T) {
+		fn, batches := batchRecorder()
+		l := NewLoader(t.Context(), fn, LoaderConfig{})
+		p1, p2 := l.Load(1), l.Load(2)
+		_, _ = p1.Get(t.Context())
+		_, _ = p2.Get(t.Context())
+		if got := batches(); len(got) == 0 || len(got) > 2 {
+			t.Errorf("got batches %v, want 1 or 2", got)
+		}
+	})
+	t.Run("concurrent", func(t *testing.T) {
+		fn, batches := batchRecorder()
+		l := NewLoader(t.Context(), fn, LoaderConfig{Wait: 20 * time.Millisecond})
+		var wg sync.WaitGroup
+		for i := range 20 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if val, _ := l.Load(i).Get(t.Context()); val != i*10 {
+					t.Errorf("got %v, want %v", val, i*10)
+				}
+			}()
+		}
+		wg.Wait()
+		if got := batches(); len(got) != 1 {
+			t.Errorf("got %d batches, want 1", len(got))
+		}
+	})
+	t.Run("max batch", func(t *testing.T) {
+		fn, batches := batchRecorder()
+		l := NewLoader(t.Context(), fn, LoaderConfig{Wait: 10 * time.Millisecond, MaxBatch: 2})
+		var ps []*Promise[int]
+		for i := range 5 {
+			ps = append(ps, l.Load(i))
+		}
+		for _, p := range ps {
+			_, _ = p.Get(t.Context())
+		}
+		got := batches()
+		slices.SortFunc(got, func(a, b []int) int { return a[0] - b[0] })
+		want := [][]int{{0, 1}, {2, 3}, {4}}
+		if !slices.EqualFunc(got, want, slices.Equal) {
+			t.Errorf("got batches %v, want %v", got, want)
+		}
+	})
+	t.Run("cache", func(t *testing.T) {
+		fn, batches := batchRecorder()
+		l := NewLoader(t.Context(), fn, LoaderConfig{})
+		p1 := l.Load(1)
+		if l.Load(1) != p1 {
+			t.Error("want same promise for the same key")
+		}
+		_, _ = p1.Get(t.Context())
+		if l.Load(1) != p1 {
+			t.Error("want cached promise")
+		}
+		l.Clear(1)
+		_, _ = l.Load(1).Get(t.Context())
+		if got := batches(); len(got) != 2 {
+			t.Errorf("got %d batches, want 2", len(got))
+		}
+	})
+	t.Run("disable cache", func(t *testing.T) {
+		fn, batches := batchRecorder()
+		l := NewLoader(t.Context(), fn, LoaderConfig{DisableCache: true})
+		_, _ = l.Load(1).Get(t.Context())
+		_, _ = l.Load(1).Get(t.Context())
+		if got := batches(); len(got) != 2 {
+			t.Errorf("got %d batches, want 2", len(got))
+		}
+	})
+	t.Run("batch error", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		calls := 0
+		l := NewLoader(t.Context(), func(ctx context.Context, keys []int) (map[int]int, error) {
+			calls++
+			return nil, errFailed
+		}, LoaderConfig{Wait: 10 * time.Millisecond})
+		p1, p2 := l.Load(1), l.Load(2)
+		for _, p := range []*Promise[int]{p1, p2} {
+			if _, err := p.Get(t.Context()); !errors.Is(err, errFailed) {
+				t.Errorf("got err = %v, want %v", err, errFailed)
+			}
+		}
+		// Failed loads are not cached.
+		_, _ = l.Load(1).Get(t.Context())
+		if calls != 2 {
+			t.Errorf("got %d calls, want 2", calls)
+		}
+	})
+	t.Run("key errors", func(t *testing.T) {
+		errOdd := errors.New("odd")
+		l := NewLoader(t.Context(), func(ctx context.Context, keys []int) (map[int]int, error) {
+			vals := map[int]int{}
+			errs := BatchErrors[int]{}
+			for _, key := range keys {
+				if key%2 == 1 {
+					errs[key] = errOdd
+				} else if key < 10 {
+					vals[key] = key * 10
+				}
+			}
+			return vals, errs
+		}, LoaderConfig{Wait: 10 * time.Millisecond})
+		p1, p2, p3 := l.Load(1), l.Load(2), l.Load(12)
+		if _, err := p1.Get(t.Context()); !errors.Is(err, errOdd) {
+			t.Errorf("got err = %v, want %v", err, errOdd)
+		}
+		if val, err := p2.Get(t.Context()); err != nil || val != 20 {
+			t.Errorf("got %v, %v; want 20, nil", val, err)
+		}
+		if _, err := p3.Get(t.Context()); !errors.Is(err, ErrNotFound) {
+			t.Errorf("got err = %v, want %v", err, ErrNotFound)
+		}
+	})
+	t.Run("panic", func(t *testing.T) {
+		l := NewLoader(t.Context(), func(ctx context.Context, keys []int) (map[int]int, error) {
+			panic("oops")
+		}, LoaderConfig{})
+		if _, err := l.Load(1).Get(t.Context()); err == nil || err.Error() != "panic: oops" {
+			t.Errorf("got err = %v, want panic: oops", err)
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package azor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Map calls the given function for each item asynchronously,
+// with at most limit calls in flight at a time. Returns a [Promise]
+// that resolves with the results in the same order as the items.
+//
+// Map fails fast: if any call fails, Map cancels the context
+// passed to the other calls, does not start new ones, and rejects
+// with the error (annotated with the item's index).
+// Use [MapAll] to process all the items regardless of errors.
+//
+// If limit is zero or negative, all the items are processed concurrently.
+// Map starts no more than limit goroutines, no matter how many items
+// there are.
+//
+// Panics if the function is nil.
+func Map[A, B any](ctx context.Context, items []A, limit int, fn func(context.Context, A) (B, error)) *Promise[[]B] {
+	if fn == nil {
+		panic("azor: nil function")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return Run(func() ([]B, error) {
+		ctx, cancel := context.WithCancelCause(ctx)
+		defer cancel(nil)
+
+		out := make([]B, len(items))
+		forEach(ctx, len(items), limit, func(i int) {
+			val, err := try(func() (B, error) { return fn(ctx, items[i]) })
+			if err != nil {
+				cancel(fmt.Errorf("item %d: %w", i, err))
+				return
+			}
+			out[i] = val
+		})
+
+		if err := context.Cause(ctx); err != nil {
+			return nil, err
+		}
+		return out, nil
+	})
+}
+
+// MapAll calls the given function for each item asynchronously,
+// with at most limit calls in flight at a time. Returns a [Promise]
+// that resolves with the results in the same order as the items.
+//
+// Unlike [Map], MapAll processes all the items even if some of
+// the calls fail, and reports the value or error for each item.
+// If the context is canceled, MapAll does not start new calls,
+// and the remaining items get the context's error.
+//
+// If limit is zero or negative, all the items are processed concurrently.
+//
+// Panics if the function is nil.
+func MapAll[A, B any](ctx context.Context, items []A, limit int, fn func(context.Context, A) (B, error)) *Promise[[]Result[B]] {
+	if fn == nil {
+		panic("azor: nil function")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return Run(func() ([]Result[B], error) {
+		out := make([]Result[B], len(items))
+		called := make([]bool, len(items))
+		forEach(ctx, len(items), limit, func(i int) {
+			called[i] = true
+			val, err := try(func() (B, error) { return fn(ctx, items[i]) })
+			out[i] = Result[B]{Val: val, Err: err}
+		})
+
+		for i := range out {
+			if !called[i] {
+				out[i].Err = ctx.Err()
+			}
+		}
+		return out, nil
+	})
+}
+
+// forEach calls fn for each index from 0 to n-1 using at most
+// limit goroutines, and waits for all the calls to finish.
+// Stops starting new calls when the context is canceled.
+func forEach(ctx context.Context, n, limit int, fn func(i int)) {
+	if limit <= 0 || limit > n {
+		limit = n
+	}
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	wg.Add(limit)
+	for range limit {
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= n || ctx.Err() != nil {
+					return
+				}
+				fn(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,216 @@
+package azor
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMap(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		items := []int{1, 2, 3, 4, 5}
+		p := Map(t.Context(), items, 2, func(ctx context.Context, n int) (string, error) {
+			// Finish in reverse order.
+			time.Sleep(time.Duration(len(items)-n) * time.Millisecond)
+			return strconv.Itoa(n * 10), nil
+		})
+
+		got, err := p.Get(t.Context())
+		if err != nil {
+			t.Fatalf("got err = %v, want nil", err)
+		}
+		want := []string{"10", "20", "30", "40", "50"}
+		if len(got) != len(want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		}
+	})
+	t.Run("limit", func(t *testing.T) {
+		var inFlight, peak atomic.Int32
+		items := make([]int, 1000)
+		p := Map(t.Context(), items, 4, func(ctx context.Context, n int) (int, error) {
+			cur := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				old := peak.Load()
+				if cur <= old || peak.CompareAndSwap(old, cur) {
+					break
+				}
+			}
+			return n, nil
+		})
+
+		if _, err := p.Get(t.Context()); err != nil {
+			t.Fatalf("got err = %v, want nil", err)
+		}
+		if peak.Load() > 4 {
+			t.Errorf("got %d calls in flight, want <= 4", peak.Load())
+		}
+	})
+	t.Run("no limit", func(t *testing.T) {
+		p := Map(t.Context(), []int{1, 2, 3}, 0, func(ctx context.Context, n int) (int, error) {
+			return n * 2, nil
+		})
+
+		got, err := p.Get(t.Context())
+		if err != nil {
+			t.Fatalf("got err = %v, want nil", err)
+		}
+		if len(got) != 3 || got[0] != 2 || got[1] != 4 || got[2] != 6 {
+			t.Errorf("got %v, want [2 4 6]", got)
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		p := Map(t.Context(), []int{}, 2, func(ctx context.Context, n int) (int, error) {
+			return n, nil
+		})
+
+		got, err := p.Get(t.Context())
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("got %v, want empty", got)
+		}
+	})
+	t.Run("fail fast", func(t *testing.T) {
+		var calls atomic.Int32
+		items := make([]int, 100)
+		for i := range items {
+			items[i] = i
+		}
+		p := Map(t.Context(), items, 2, func(ctx context.Context, n int) (int, error) {
+			calls.Add(1)
+			if n == 3 {
+				return 0, errDummy
+			}
+			return n, nil
+		})
+
+		got, err := p.Get(t.Context())
+		if !errors.Is(err, errDummy) {
+			t.Errorf("got err = %v, want %v", err, errDummy)
+		}
+		if err != nil && err.Error() != "item 3: dummy" {
+			t.Errorf("got err = %q, want %q", err, "item 3: dummy")
+		}
+		if got != nil {
+			t.Errorf("got %v, want nil", got)
+		}
+		if calls.Load() >= 100 {
+			t.Errorf("got %d calls, want fewer", calls.Load())
+		}
+	})
+	t.Run("panic", func(t *testing.T) {
+		p := Map(t.Context(), []int{1}, 1, func(ctx context.Context, n int) (int, error) {
+			panic("oops")
+		})
+
+		_, err := p.Get(t.Context())
+		want := "item 0: panic: oops"
+		if err == nil || err.Error() != want {
+			t.Errorf("got err = %q, want %q", err, want)
+		}
+	})
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		p := Map(ctx, []int{1, 2}, 1, func(ctx context.Context, n int) (int, error) {
+			return n, nil
+		})
+
+		_, err := p.Get(t.Context())
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got err = %v, want %v", err, context.Canceled)
+		}
+	})
+	t.Run("nil function", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("should panic for nil function")
+			}
+		}()
+		Map[int, int](t.Context(), nil, 1, nil)
+	})
+}
+
+func TestMapAll(t *testing.T) {
+	t.Run("collect all", func(t *testing.T) {
+		p := MapAll(t.Context(), []int{1, 2, 3, 4}, 2, func(ctx context.Context, n int) (int, error) {
+			if n%2 == 0 {
+				return 0, errDummy
+			}
+			return n * 10, nil
+		})
+
+		got, err := p.Get(t.Context())
+		if err != nil {
+			t.Fatalf("got err = %v, want nil", err)
+		}
+		want := []Result[int]{{10, nil}, {0, errDummy}, {30, nil}, {0, errDummy}}
+		if len(got) != len(want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("item %d: got %v, want %v", i, got[i], want[i])
+			}
+		}
+	})
+	t.Run("panic", func(t *testing.T) {
+		p := MapAll(t.Context(), []int{1, 2}, 1, func(ctx context.Context, n int) (int, error) {
+			if n == 1 {
+				panic("oops")
+			}
+			return n, nil
+		})
+
+		got, err := p.Get(t.Context())
+		if err != nil {
+			t.Fatalf("got err = %v, want nil", err)
+		}
+		if got[0].Err == nil || got[0].Err.Error() != "panic: oops" {
+			t.Errorf("got err = %v, want panic", got[0].Err)
+		}
+		if got[1].Val != 2 || got[1].Err != nil {
+			t.Errorf("got %v, want {2 <nil>}", got[1])
+		}
+	})
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		p := MapAll(ctx, []int{1, 2, 3}, 1, func(ctx context.Context, n int) (int, error) {
+			if n == 1 {
+				cancel()
+			}
+			return n, nil
+		})
+
+		got, err := p.Get(t.Context())
+		if err != nil {
+			t.Fatalf("got err = %v, want nil", err)
+		}
+		if got[0].Val != 1 || got[0].Err != nil {
+			t.Errorf("got %v, want {1 <nil>}", got[0])
+		}
+		for _, res := range got[1:] {
+			if !errors.Is(res.Err, context.Canceled) {
+				t.Errorf("got err = %v, want %v", res.Err, context.Canceled)
+			}
+		}
+	})
+	t.Run("nil function", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("should panic for nil function")
+			}
+		}()
+		MapAll[int, int](t.Context(), nil, 1, nil)
+	})
+}
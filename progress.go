@@ -0,0 +1,53 @@
+package azor
+
+import "github.com/nalgeon/azor/promise"
+
+// Progress is a [Promise] that also reports the progress
+// of the asynchronous call before it settles.
+// Use [RunProgress] to create a new progress promise.
+type Progress[T, P any] struct {
+	*Promise[T]
+}
+
+// RunProgress calls the given function asynchronously and returns
+// a [Progress] promise, like [Run] does. In addition, the function
+// receives a notify function to report its progress to the handlers
+// registered with [Progress.OnProgress].
+//
+// Notifications are delivered synchronously and in order.
+// Notifications made after the function returns are ignored.
+//
+// Panics if the given function is nil.
+func RunProgress[T, P any](fn func(notify func(P)) (T, error)) *Progress[T, P] {
+	if fn == nil {
+		panic("azor: nil function")
+	}
+	p := promise.NewProgress(func(resolve func(any), reject func(error), notify func(any)) {
+		val, err := fn(func(v P) { notify(v) })
+		if err != nil {
+			reject(err)
+			return
+		}
+		resolve(val)
+	})
+	return &Progress[T, P]{&Promise[T]{p}}
+}
+
+// OnProgress registers a handler to be called with the progress
+// notifications until the promise settles. If there were notifications
+// before OnProgress was called, the handler is called right away
+// with the latest one.
+//
+// Returns the same promise, so you can chain the call.
+func (p *Progress[T, P]) OnProgress(fn func(P)) *Progress[T, P] {
+	if fn == nil {
+		return p
+	}
+	p.p.OnProgress(func(v any) {
+		// A nil notification of an interface type
+		// arrives as a nil any, so pass the zero value.
+		pv, _ := v.(P)
+		fn(pv)
+	})
+	return p
+}
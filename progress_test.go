@@ -0,0 +1,109 @@
+package azor
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestRunProgress(t *testing.T) {
+	t.Run("notifications", func(t *testing.T) {
+		start := make(chan struct{})
+		p := RunProgress(func(notify func(int)) (string, error) {
+			<-start
+			for i := range 5 {
+				notify(i * 25)
+			}
+			return "done", nil
+		})
+
+		var mu sync.Mutex
+		var got []int
+		p.OnProgress(func(pct int) {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, pct)
+		})
+		close(start)
+
+		val, err := p.Get(t.Context())
+		if err != nil || val != "done" {
+			t.Errorf("got %v, %v; want done, nil", val, err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if !slices.Equal(got, []int{0, 25, 50, 75, 100}) {
+			t.Errorf("got %v, want [0 25 50 75 100]", got)
+		}
+	})
+	t.Run("latest on subscribe", func(t *testing.T) {
+		notified := make(chan struct{})
+		finish := make(chan struct{})
+		p := RunProgress(func(notify func(int)) (int, error) {
+			notify(1)
+			notify(2)
+			close(notified)
+			<-finish
+			return 0, nil
+		})
+		<-notified
+
+		var got []int
+		p.OnProgress(func(n int) { got = append(got, n) })
+		close(finish)
+		<-p.Done()
+		if !slices.Equal(got, []int{2}) {
+			t.Errorf("got %v, want [2]", got)
+		}
+	})
+	t.Run("after settle", func(t *testing.T) {
+		var notify func(int)
+		p := RunProgress(func(fn func(int)) (int, error) {
+			notify = fn
+			return 0, errors.New("failed")
+		})
+		<-p.Done()
+
+		called := false
+		p.OnProgress(func(int) { called = true })
+		notify(1)
+		if called {
+			t.Error("handler called after settle")
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		p := RunProgress(func(notify func(int)) (int, error) {
+			return 0, errFailed
+		})
+		if _, err := p.Get(t.Context()); !errors.Is(err, errFailed) {
+			t.Errorf("got err = %v, want %v", err, errFailed)
+		}
+	})
+	t.Run("nil interface", func(t *testing.T) {
+		start := make(chan struct{})
+		p := RunProgress(func(notify func(error)) (int, error) {
+			<-start
+			notify(nil)
+			notify(errDummy)
+			return 42, nil
+		})
+		var mu sync.Mutex
+		var got []error
+		p.OnProgress(func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, err)
+		})
+		close(start)
+		if val, err := p.Get(t.Context()); val != 42 || err != nil {
+			t.Errorf("got %v, %v; want 42, nil", val, err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if !slices.Equal(got, []error{nil, errDummy}) {
+			t.Errorf("got %v, want [<nil> dummy]", got)
+		}
+	})
+}
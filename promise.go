@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/nalgeon/azor/internal/safe"
 	"github.com/nalgeon/azor/promise"
 )
 
@@ -20,6 +21,13 @@ type Promise[T any] struct {
 	p *promise.Promise
 }
 
+// Result represents the result of an asynchronous call:
+// either a value or an error.
+type Result[T any] struct {
+	Val T
+	Err error
+}
+
 // Run calls the given function asynchronously and returns a [Promise].
 // The promise will resolve with the function's result,
 // or reject with an error if the function returns one or panics.
@@ -41,6 +49,18 @@ func Run[T any](fn func() (T, error)) *Promise[T] {
 	}
 }
 
+// WithResolvers creates a new pending [Promise] and returns it
+// together with the functions that resolve or reject it.
+// Use it when the result is produced by code that does not fit
+// into a single function call, such as a callback or a batch.
+//
+// The promise stays pending until resolve or reject is called.
+// Only the first call has an effect.
+func WithResolvers[T any]() (p *Promise[T], resolve func(T), reject func(error)) {
+	pp, res, rej := promise.WithResolvers()
+	return &Promise[T]{pp}, func(val T) { res(val) }, rej
+}
+
 // Get waits for the promise to settle and returns the result.
 // If the context is canceled before the promise is settled,
 // returns a zero value and the context's error.
@@ -86,3 +106,39 @@ func (p *Promise[T]) Get(ctx context.Context) (T, error) {
 func (p *Promise[T]) Done() <-chan struct{} {
 	return p.p.Done()
 }
+
+// Chan returns a channel that receives the result when
+// the promise settles. The channel is closed after the result
+// is sent. Use it to wait for a promise in a select statement
+// along with other channels.
+//
+// Each call returns a new channel. The channel is buffered,
+// so the result is not lost if nobody is receiving at the time
+// the promise settles.
+func (p *Promise[T]) Chan() <-chan Result[T] {
+	ch := make(chan Result[T], 1)
+	go func() {
+		defer close(ch)
+		val, err := p.Get(context.Background())
+		ch <- Result[T]{Val: val, Err: err}
+	}()
+	return ch
+}
+
+// resolved returns a promise that is already
+// fulfilled with the given value.
+func resolved[T any](val T) *Promise[T] {
+	return &Promise[T]{promise.Resolve(val)}
+}
+
+// rejected returns a promise that is already
+// rejected with the given error.
+func rejected[T any](err error) *Promise[T] {
+	return &Promise[T]{promise.Reject(err)}
+}
+
+// try calls the given function and turns a panic
+// into an error, the same way [Run] does.
+func try[T any](fn func() (T, error)) (T, error) {
+	return safe.Call(fn)
+}
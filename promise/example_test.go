@@ -30,6 +30,24 @@ func (s storage) Set(ctx context.Context, key, value string) error {
 
 var db = make(storage)
 
+func ExampleAsyncLocal() {
+	var tenant promise.AsyncLocal[string]
+
+	var p *promise.Promise
+	tenant.Run("acme", func() {
+		p = promise.Resolve(42).Then(func(value any) any {
+			// The handler sees the value set by Run.
+			name, _ := tenant.Get()
+			fmt.Printf("tenant %s got %v\n", name, value)
+			return nil
+		})
+	})
+	<-p.Done()
+
+	// Output:
+	// tenant acme got 42
+}
+
 func ExampleNew() {
 	p := promise.New(func(resolve func(any), reject func(error)) {
 		// Resolve with a value.
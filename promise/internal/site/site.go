@@ -0,0 +1,60 @@
+// Package site records where promise goroutines are started,
+// so that promisetest can tell which code leaked them.
+// Recording is off unless a leak check turns it on.
+package site
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Site is where a promise goroutine was started.
+type Site struct {
+	PCs    []uintptr // call stack of the code that created the promise
+	Parent uint64    // id of the goroutine that created the promise
+}
+
+var (
+	recording atomic.Int64 // number of leak checks in progress
+	sites     sync.Map     // goroutine id -> *Site
+)
+
+// Record turns the recording on and returns
+// a function that turns it off.
+func Record() (stop func()) {
+	recording.Add(1)
+	var once sync.Once
+	return func() {
+		once.Do(func() { recording.Add(-1) })
+	}
+}
+
+// Enabled reports whether the recording is on.
+func Enabled() bool {
+	return recording.Load() > 0
+}
+
+// Capture returns the site of the calling code,
+// skipping the given number of frames (as in [runtime.Callers]).
+func Capture(skip int, parent uint64) *Site {
+	var pcs [32]uintptr
+	n := runtime.Callers(skip+1, pcs[:])
+	return &Site{PCs: pcs[:n:n], Parent: parent}
+}
+
+// Set sets the site of the goroutine with the given id
+// and returns a function that removes it.
+func Set(id uint64, s *Site) (unset func()) {
+	sites.Store(id, s)
+	return func() { sites.Delete(id) }
+}
+
+// Lookup returns the site of the goroutine with the given id.
+func Lookup(id uint64) (*Site, bool) {
+	s, ok := sites.Load(id)
+	if !ok {
+		return nil, false
+	}
+	return s.(*Site), true
+}
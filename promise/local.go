@@ -0,0 +1,127 @@
+package promise
+
+import (
+	"bytes"
+	"maps"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// AsyncLocal stores a value that is local to an asynchronous flow,
+// similar to AsyncLocalStorage in Node.js. Use it to pass request-scoped
+// values (such as a tenant ID or a logger) to promise handlers that
+// don't take a context.
+//
+// The value set with [AsyncLocal.Run] is available to the function
+// passed to Run, and to all the executors and handlers of the promises
+// created while it runs (directly or down the chain):
+//
+//	var tenant promise.AsyncLocal[string]
+//	tenant.Run("acme", func() {
+//	    promise.New(func(resolve func(any), reject func(error)) {
+//	        resolve(42)
+//	    }).Then(func(value any) any {
+//	        name, _ := tenant.Get() // "acme"
+//	        return name
+//	    })
+//	})
+//
+// Promises capture the values when they are created ([New]) or chained
+// ([Promise.Then], [Promise.Catch], [Promise.Finally]), and restore them
+// in the goroutines that run the executor or handlers. Goroutines started
+// with the go statement don't inherit the values.
+//
+// Capturing the values takes a goroutine id lookup, which makes
+// creating promises slower. The lookup only happens while some
+// goroutine has values set (inside Run or a promise created there).
+//
+// The zero value is ready to use. An AsyncLocal must not be copied
+// after first use.
+type AsyncLocal[T any] struct {
+	_ byte // makes the address of each AsyncLocal unique
+}
+
+// Run sets the value for the duration of fn and for the promises
+// created while fn runs, then calls fn. Nested calls to Run
+// (with the same or other AsyncLocal) override the value
+// for their own duration only.
+func (l *AsyncLocal[T]) Run(value T, fn func()) {
+	if fn == nil {
+		panic("promise: nil function")
+	}
+	frame := maps.Clone(currentFrame())
+	if frame == nil {
+		frame = make(frameMap, 1)
+	}
+	frame[l] = value
+	defer setFrame(frame)()
+	fn()
+}
+
+// Get returns the current value and true if the value is set,
+// or a zero value and false otherwise.
+func (l *AsyncLocal[T]) Get() (T, bool) {
+	val, ok := currentFrame()[l]
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return val.(T), true
+}
+
+// frameMap maps AsyncLocals to their values in a goroutine.
+// Frames are never modified after they are set, so they
+// can be safely shared between goroutines.
+type frameMap map[any]any
+
+// locals holds the frames for all the goroutines that have them.
+var locals struct {
+	active atomic.Int64 // number of frames set and not yet restored
+	frames sync.Map     // goroutine id -> frameMap
+}
+
+// currentFrame returns the frame of the current goroutine,
+// or nil if there is none.
+func currentFrame() frameMap {
+	// Looking up the goroutine id is expensive,
+	// so skip it when no goroutine has a frame.
+	if locals.active.Load() == 0 {
+		return nil
+	}
+	frame, ok := locals.frames.Load(goid())
+	if !ok {
+		return nil
+	}
+	return frame.(frameMap)
+}
+
+// setFrame sets the frame of the current goroutine
+// and returns a function that restores the previous one.
+func setFrame(frame frameMap) (restore func()) {
+	id := goid()
+	locals.active.Add(1)
+	prev, hadPrev := locals.frames.Swap(id, frame)
+	return func() {
+		defer locals.active.Add(-1)
+		if hadPrev {
+			locals.frames.Store(id, prev)
+		} else {
+			locals.frames.Delete(id)
+		}
+	}
+}
+
+// goid returns the id of the current goroutine.
+func goid() uint64 {
+	// The stack trace starts with "goroutine 123 [running]:".
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	s := bytes.TrimPrefix(buf[:n], []byte("goroutine "))
+	if i := bytes.IndexByte(s, ' '); i >= 0 {
+		s = s[:i]
+	}
+	id, _ := strconv.ParseUint(string(s), 10, 64)
+	return id
+}
@@ -0,0 +1,175 @@
+package promise
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAsyncLocal(t *testing.T) {
+	t.Run("unset", func(t *testing.T) {
+		var local AsyncLocal[string]
+		val, ok := local.Get()
+		if ok || val != "" {
+			t.Errorf("got %q, %v; want empty, false", val, ok)
+		}
+	})
+	t.Run("run", func(t *testing.T) {
+		var local AsyncLocal[string]
+		local.Run("foo", func() {
+			if val, ok := local.Get(); !ok || val != "foo" {
+				t.Errorf("got %q, %v; want foo, true", val, ok)
+			}
+		})
+		if _, ok := local.Get(); ok {
+			t.Error("value should not be set after Run")
+		}
+	})
+	t.Run("nested", func(t *testing.T) {
+		var local AsyncLocal[string]
+		var other AsyncLocal[int]
+		local.Run("outer", func() {
+			other.Run(42, func() {
+				local.Run("inner", func() {
+					if val, _ := local.Get(); val != "inner" {
+						t.Errorf("got %q, want inner", val)
+					}
+					if val, _ := other.Get(); val != 42 {
+						t.Errorf("got %v, want 42", val)
+					}
+				})
+				if val, _ := local.Get(); val != "outer" {
+					t.Errorf("got %q, want outer", val)
+				}
+			})
+			if _, ok := other.Get(); ok {
+				t.Error("other value should not be set")
+			}
+		})
+	})
+	t.Run("executor", func(t *testing.T) {
+		var local AsyncLocal[string]
+		var p *Promise
+		local.Run("foo", func() {
+			p = New(func(resolve func(any), reject func(error)) {
+				val, _ := local.Get()
+				resolve(val)
+			})
+		})
+		<-p.Done()
+		if p.res.val != "foo" {
+			t.Errorf("got %v, want foo", p.res.val)
+		}
+	})
+	t.Run("then chain", func(t *testing.T) {
+		var local AsyncLocal[string]
+		get := func(any) any {
+			val, _ := local.Get()
+			return val
+		}
+		var p *Promise
+		local.Run("foo", func() {
+			p = Resolve(dummy).Then(get).Then(func(any) any {
+				return errDummy
+			}).Catch(func(error) any {
+				return get(nil)
+			}).Finally(func() any {
+				if val, _ := local.Get(); val != "foo" {
+					return errDummy
+				}
+				return nil
+			})
+		})
+		<-p.Done()
+		if p.res.err != nil {
+			t.Errorf("got err %v, want nil", p.res.err)
+		}
+		if p.res.val != "foo" {
+			t.Errorf("got %v, want foo", p.res.val)
+		}
+	})
+	t.Run("chained later", func(t *testing.T) {
+		// Handlers see the values at the time Then is called,
+		// not when the promise was created.
+		var local AsyncLocal[string]
+		var p *Promise
+		local.Run("foo", func() {
+			p = Resolve(dummy)
+		})
+		local.Run("bar", func() {
+			p = p.Then(func(any) any {
+				val, _ := local.Get()
+				return val
+			})
+		})
+		<-p.Done()
+		if p.res.val != "bar" {
+			t.Errorf("got %v, want bar", p.res.val)
+		}
+	})
+	t.Run("concurrent", func(t *testing.T) {
+		var local AsyncLocal[int]
+		var wg sync.WaitGroup
+		for i := range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				local.Run(i, func() {
+					p := New(func(resolve func(any), reject func(error)) {
+						val, _ := local.Get()
+						resolve(val)
+					})
+					<-p.Done()
+					if p.res.val != i {
+						t.Errorf("got %v, want %v", p.res.val, i)
+					}
+				})
+			}()
+		}
+		wg.Wait()
+	})
+	t.Run("frames released", func(t *testing.T) {
+		var local AsyncLocal[string]
+		local.Run("foo", func() {
+			p := New(func(resolve func(any), reject func(error)) {
+				resolve(nil)
+			})
+			<-p.Done()
+		})
+		// The executor goroutine restores its frame
+		// right after settling the promise.
+		n := 0
+		for range 100 {
+			n = 0
+			locals.frames.Range(func(_, _ any) bool {
+				n++
+				return true
+			})
+			if n == 0 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		if n != 0 {
+			t.Errorf("got %d frames, want 0", n)
+		}
+		if n := locals.active.Load(); n != 0 {
+			t.Errorf("got %d active frames, want 0", n)
+		}
+	})
+}
+
+func TestGoid(t *testing.T) {
+	id := goid()
+	if id == 0 {
+		t.Fatal("got zero goroutine id")
+	}
+	if goid() != id {
+		t.Error("goroutine id changed")
+	}
+	done := make(chan uint64)
+	go func() { done <- goid() }()
+	if other := <-done; other == id || other == 0 {
+		t.Errorf("got %d in other goroutine, want different non-zero id", other)
+	}
+}
@@ -0,0 +1,84 @@
+package promise
+
+import "sync"
+
+// progress holds the progress handlers of a promise.
+type progress struct {
+	mu       sync.Mutex // serializes notifications
+	handlers []func(any)
+	last     any  // latest notification
+	notified bool // true if there was a notification
+}
+
+// NewProgress creates a new promise that will be resolved or rejected
+// based on the execution of the given function, like [New] does.
+// In addition, the executor receives a notify function to report
+// the progress of the operation before the promise settles.
+//
+// Notify calls the handlers registered with [Promise.OnProgress]
+// synchronously and in order, so the handlers see the notifications
+// in the same order the executor makes them. Notifications made after
+// the promise is settled are ignored.
+//
+// NewProgress panics if fn is nil.
+func NewProgress(fn func(resolve func(any), reject func(error), notify func(any))) *Promise {
+	if fn == nil {
+		panic("promise: nil function")
+	}
+	p := newPromise()
+	go p.run(currentFrame(), newSite(), func() {
+		fn(p.resolve, p.reject, p.notify)
+	})
+	return p
+}
+
+// OnProgress registers a handler to be called with the progress
+// notifications made by the executor (see [NewProgress]) until
+// the promise settles. If there were notifications before
+// OnProgress was called, the handler is called right away
+// with the latest one, so it does not miss the current progress.
+//
+// Returns the same promise, so you can chain the call.
+// Promises returned by Then, Catch and Finally do not receive
+// the progress notifications of the original promise.
+//
+// The handler must not call OnProgress on the same promise.
+func (p *Promise) OnProgress(onProgress func(any)) *Promise {
+	if onProgress == nil {
+		return p
+	}
+	p.prog.mu.Lock()
+	defer p.prog.mu.Unlock()
+	if p.settled() {
+		return p
+	}
+	p.prog.handlers = append(p.prog.handlers, onProgress)
+	if p.prog.notified {
+		onProgress(p.prog.last)
+	}
+	return p
+}
+
+// notify calls the progress handlers with the given value,
+// unless the promise is already settled.
+func (p *Promise) notify(value any) {
+	p.prog.mu.Lock()
+	defer p.prog.mu.Unlock()
+	if p.settled() {
+		return
+	}
+	p.prog.last, p.prog.notified = value, true
+	for _, handler := range p.prog.handlers {
+		handler(value)
+	}
+}
+
+// settled reports whether the promise is settled.
+func (p *Promise) settled() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
@@ -14,6 +14,8 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+
+	"github.com/nalgeon/azor/promise/internal/site"
 )
 
 // result represents the result of a promise.
@@ -35,6 +37,7 @@ type Promise struct {
 	res  result
 	done chan struct{}
 	once sync.Once
+	prog progress
 }
 
 // New creates a new promise that will be resolved or rejected
@@ -42,6 +45,7 @@ type Promise struct {
 //
 // The executor function runs in a new goroutine.
 // Panics in the executor are caught and cause the promise to be rejected.
+// The executor sees the same [AsyncLocal] values as the caller of New.
 //
 // New panics if fn is nil.
 func New(fn func(func(any), func(error))) *Promise {
@@ -49,13 +53,38 @@ func New(fn func(func(any), func(error))) *Promise {
 		panic("promise: nil function")
 	}
 	p := newPromise()
-	go func() {
-		defer p.rejectOnPanic()
+	go p.run(currentFrame(), newSite(), func() {
 		fn(p.resolve, p.reject)
-	}()
+	})
 	return p
 }
 
+// run calls the executor in the current goroutine.
+// Restores the async-local values of the goroutine
+// that created the promise, records where it was created
+// (if a leak check asked for it), and rejects the promise
+// if the executor panics.
+func (p *Promise) run(frame frameMap, s *site.Site, executor func()) {
+	if frame != nil {
+		defer setFrame(frame)()
+	}
+	if s != nil {
+		defer site.Set(goid(), s)()
+	}
+	defer p.rejectOnPanic()
+	executor()
+}
+
+// newSite returns where the promise is being created, if a leak
+// check is in progress (see promisetest.VerifyNoLeaks), or nil.
+// Must be called directly from the function that creates the promise.
+func newSite() *site.Site {
+	if !site.Enabled() {
+		return nil
+	}
+	return site.Capture(3, goid())
+}
+
 // newPromise creates a new pending promise.
 func newPromise() *Promise {
 	return &Promise{
@@ -251,3 +280,56 @@ func Reject(err error) *Promise {
 	p.reject(err)
 	return p
 }
+
+// WithResolvers creates a new pending promise and returns it
+// together with the functions that resolve or reject it.
+// It's the equivalent of Promise.withResolvers in JavaScript.
+//
+// Unlike [New], it does not start a goroutine: the promise stays
+// pending until resolve or reject is called. Only the first call
+// has an effect.
+func WithResolvers() (*Promise, func(any), func(error)) {
+	p := newPromise()
+	return p, p.resolve, p.reject
+}
+
+// ErrClosed is the error that a promise created by [FromChan]
+// rejects with if the channel is closed without sending a value.
+var ErrClosed = errors.New("channel closed without value")
+
+// FromChan creates a new promise that resolves with the first
+// value received from the channel, or rejects with [ErrClosed]
+// if the channel is closed without sending a value.
+// As with [Resolve], receiving an error rejects the promise.
+//
+// The promise only receives one value from the channel.
+func FromChan[T any](ch <-chan T) *Promise {
+	return New(func(resolve func(any), reject func(error)) {
+		val, ok := <-ch
+		if !ok {
+			reject(ErrClosed)
+			return
+		}
+		resolve(val)
+	})
+}
+
+// ToChan returns a channel that receives the promise's result when
+// the promise settles: the value if it is fulfilled, or the error
+// if it is rejected. The channel is closed after the result is sent.
+//
+// The channel is buffered, so the result is not lost if nobody
+// is receiving at the time the promise settles.
+func ToChan(p *Promise) <-chan any {
+	ch := make(chan any, 1)
+	go func() {
+		defer close(ch)
+		p.wait()
+		if p.res.err != nil {
+			ch <- p.res.err
+		} else {
+			ch <- p.res.val
+		}
+	}()
+	return ch
+}
@@ -466,3 +466,197 @@ func TestResolve(t *testing.T) {
 		}
 	})
 }
+
+func TestWithResolvers(t *testing.T) {
+	t.Run("pending", func(t *testing.T) {
+		p, _, _ := WithResolvers()
+		select {
+		case <-p.Done():
+			t.Error("promise should not be settled")
+		default:
+			// ok
+		}
+	})
+	t.Run("resolve", func(t *testing.T) {
+		p, resolve, _ := WithResolvers()
+		resolve(dummy)
+		<-p.Done()
+		if p.res.err != nil {
+			t.Errorf("got err %v, want nil", p.res.err)
+		}
+		if p.res.val != dummy {
+			t.Errorf("got value %v, want %v", p.res.val, dummy)
+		}
+	})
+	t.Run("reject", func(t *testing.T) {
+		p, _, reject := WithResolvers()
+		reject(errDummy)
+		<-p.Done()
+		if !errors.Is(p.res.err, errDummy) {
+			t.Errorf("got err %v, want %v", p.res.err, errDummy)
+		}
+		if p.res.val != nil {
+			t.Errorf("got value %v, want nil", p.res.val)
+		}
+	})
+	t.Run("settle once", func(t *testing.T) {
+		p, resolve, reject := WithResolvers()
+		resolve(dummy)
+		reject(errDummy)
+		resolve("foo")
+		<-p.Done()
+		if p.res.err != nil {
+			t.Errorf("got err %v, want nil", p.res.err)
+		}
+		if p.res.val != dummy {
+			t.Errorf("got value %v, want %v", p.res.val, dummy)
+		}
+	})
+}
+
+func TestFromChan(t *testing.T) {
+	t.Run("value", func(t *testing.T) {
+		ch := make(chan any, 2)
+		ch <- dummy
+		ch <- "foo"
+		p := FromChan(ch)
+		<-p.Done()
+		if p.res.err != nil {
+			t.Errorf("got err %v, want nil", p.res.err)
+		}
+		if p.res.val != dummy {
+			t.Errorf("got value %v, want %v", p.res.val, dummy)
+		}
+		if len(ch) != 1 {
+			t.Errorf("got %d values left, want 1", len(ch))
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		ch := make(chan error, 1)
+		ch <- errDummy
+		p := FromChan(ch)
+		<-p.Done()
+		if !errors.Is(p.res.err, errDummy) {
+			t.Errorf("got err %v, want %v", p.res.err, errDummy)
+		}
+	})
+	t.Run("closed", func(t *testing.T) {
+		ch := make(chan int)
+		close(ch)
+		p := FromChan(ch)
+		<-p.Done()
+		if !errors.Is(p.res.err, ErrClosed) {
+			t.Errorf("got err %v, want %v", p.res.err, ErrClosed)
+		}
+	})
+	t.Run("pending", func(t *testing.T) {
+		ch := make(chan int)
+		p := FromChan(ch)
+		select {
+		case <-p.Done():
+			t.Error("promise should not be settled")
+		case <-time.After(10 * time.Millisecond):
+			// ok
+		}
+		close(ch)
+	})
+}
+
+func TestToChan(t *testing.T) {
+	t.Run("fulfilled", func(t *testing.T) {
+		ch := ToChan(Resolve(dummy))
+		if val := <-ch; val != dummy {
+			t.Errorf("got value %v, want %v", val, dummy)
+		}
+		if _, ok := <-ch; ok {
+			t.Error("channel should be closed")
+		}
+	})
+	t.Run("rejected", func(t *testing.T) {
+		ch := ToChan(Reject(errDummy))
+		if val := <-ch; val != errDummy {
+			t.Errorf("got value %v, want %v", val, errDummy)
+		}
+		if _, ok := <-ch; ok {
+			t.Error("channel should be closed")
+		}
+	})
+	t.Run("pending", func(t *testing.T) {
+		p, resolve, _ := WithResolvers()
+		ch := ToChan(p)
+		select {
+		case <-ch:
+			t.Error("channel should not receive")
+		case <-time.After(10 * time.Millisecond):
+			// ok
+		}
+		resolve(dummy)
+		if val := <-ch; val != dummy {
+			t.Errorf("got value %v, want %v", val, dummy)
+		}
+	})
+}
+
+func TestOnProgress(t *testing.T) {
+	t.Run("in order", func(t *testing.T) {
+		start := make(chan struct{})
+		p := NewProgress(func(resolve func(any), reject func(error), notify func(any)) {
+			<-start
+			for i := range 10 {
+				notify(i)
+			}
+			resolve(dummy)
+		})
+		var got []any
+		p.OnProgress(func(v any) { got = append(got, v) })
+		close(start)
+		<-p.Done()
+		for i, v := range got {
+			if v != i {
+				t.Fatalf("got %v, want 0..9 in order", got)
+			}
+		}
+		if len(got) != 10 {
+			t.Errorf("got %d notifications, want 10", len(got))
+		}
+	})
+	t.Run("multiple handlers", func(t *testing.T) {
+		start := make(chan struct{})
+		p := NewProgress(func(resolve func(any), reject func(error), notify func(any)) {
+			<-start
+			notify(1)
+			resolve(dummy)
+		})
+		var n1, n2 int
+		p.OnProgress(func(any) { n1++ }).OnProgress(func(any) { n2++ })
+		close(start)
+		<-p.Done()
+		if n1 != 1 || n2 != 1 {
+			t.Errorf("got %d and %d calls, want 1 and 1", n1, n2)
+		}
+	})
+	t.Run("stops after settle", func(t *testing.T) {
+		var notify func(any)
+		p := NewProgress(func(resolve func(any), reject func(error), fn func(any)) {
+			notify = fn
+			reject(errDummy)
+		})
+		var calls atomic.Int32
+		p.OnProgress(func(any) { calls.Add(1) })
+		<-p.Done()
+		notify(1)
+		if n := calls.Load(); n != 0 {
+			t.Errorf("got %d calls, want 0", n)
+		}
+	})
+	t.Run("no notifications", func(t *testing.T) {
+		called := false
+		p := New(func(resolve func(any), reject func(error)) {
+			resolve(dummy)
+		}).OnProgress(func(any) { called = true })
+		<-p.Done()
+		if called {
+			t.Error("handler should not be called")
+		}
+	})
+}
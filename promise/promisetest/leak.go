@@ -0,0 +1,241 @@
+package promisetest
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nalgeon/azor/promise"
+	"github.com/nalgeon/azor/promise/internal/site"
+)
+
+// Import paths used to tell library goroutines from the others.
+var (
+	modulePath = path.Dir(reflect.TypeFor[promise.Promise]().PkgPath())
+	selfPath   = reflect.TypeFor[result]().PkgPath()
+)
+
+// VerifyNoLeaks checks that the test does not leak goroutines
+// started by promise executors and handlers.
+//
+// Call it at the beginning of a test. It takes a snapshot of
+// the running goroutines, and at cleanup fails the test if any
+// goroutines started by this library since then are still running.
+// Goroutines have up to [Timeout] to finish before they are
+// considered leaked. Goroutines that do not belong to this library
+// are ignored.
+//
+// The failure message tells where each leaked goroutine's promise
+// was created: the innermost frame outside this library (or in a test
+// file) at the time of the call to New, Then, or a similar function.
+// To make that possible, VerifyNoLeaks records the call stacks of the
+// promises created until the end of the test.
+func VerifyNoLeaks(tb testing.TB) {
+	tb.Helper()
+	tb.Cleanup(site.Record())
+	before := make(map[string]bool)
+	for _, g := range goroutines() {
+		before[g.id] = true
+	}
+
+	tb.Cleanup(func() {
+		tb.Helper()
+		var leaked []goroutine
+		deadline := time.Now().Add(Timeout)
+		for {
+			leaked = leaked[:0]
+			for _, g := range goroutines() {
+				if !before[g.id] {
+					leaked = append(leaked, g)
+				}
+			}
+			if len(leaked) == 0 || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		if len(leaked) == 0 {
+			return
+		}
+
+		var b strings.Builder
+		for _, g := range leaked {
+			b.WriteString("\n")
+			b.WriteString(g.String())
+		}
+		tb.Errorf("leaked goroutines (%d):%s", len(leaked), b.String())
+	})
+}
+
+// goroutine describes a running goroutine
+// started by this library.
+type goroutine struct {
+	id        string // goroutine id
+	parent    string // id of the goroutine that started this one
+	state     string // e.g. "chan receive"
+	createdBy frame  // the go statement that started the goroutine
+	madeAt    frame  // the code that created the goroutine's promise
+	origin    frame  // the innermost frame outside the library
+	top       frame  // the innermost frame
+}
+
+// String returns a description of the goroutine
+// suitable for a test failure message.
+func (g goroutine) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "goroutine %s [%s]:\n", g.id, g.state)
+	fmt.Fprintf(&b, "    created by %s\n", g.createdBy)
+	if g.madeAt.fn != "" {
+		fmt.Fprintf(&b, "    promise made in %s\n", g.madeAt)
+	}
+	if g.origin.fn != "" {
+		fmt.Fprintf(&b, "    running %s\n", g.origin)
+	}
+	fmt.Fprintf(&b, "    blocked in %s\n", g.top)
+	return b.String()
+}
+
+// frame is a single stack frame.
+type frame struct {
+	fn   string // function name
+	file string // file:line
+}
+
+// String returns the frame as "function at file:line".
+func (f frame) String() string {
+	return f.fn + " at " + f.file
+}
+
+// goroutines returns the running goroutines
+// started by this library.
+func goroutines() []goroutine {
+	all := make(map[string]goroutine)
+	var gs []goroutine
+	for _, block := range bytes.Split(stacks(), []byte("\n\n")) {
+		g, ok := parseGoroutine(string(block))
+		if !ok {
+			continue
+		}
+		all[g.id] = g
+		if isLibrary(g.createdBy.fn) {
+			gs = append(gs, g)
+		}
+	}
+	for i := range gs {
+		gs[i].madeAt = madeAt(gs[i].id, all)
+	}
+	return gs
+}
+
+// madeAt returns the code that created the promise of the goroutine
+// with the given id: the innermost user frame of the stack recorded
+// when the promise was created. If the promise was created by the
+// library itself (for example, in a handler), follows the goroutine
+// that created it. If there is no recorded stack, uses the current
+// stack of the parent goroutine, if it's still running.
+func madeAt(id string, all map[string]goroutine) frame {
+	for range 100 { // guards against cycles
+		n, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return frame{}
+		}
+		s, ok := site.Lookup(n)
+		if !ok {
+			if parent, ok := all[all[id].parent]; ok {
+				return parent.origin
+			}
+			return frame{}
+		}
+		frames := runtime.CallersFrames(s.PCs)
+		for {
+			f, more := frames.Next()
+			if isUser(f.Function, f.File) {
+				return frame{fn: f.Function, file: fmt.Sprintf("%s:%d", f.File, f.Line)}
+			}
+			if !more {
+				break
+			}
+		}
+		id = strconv.FormatUint(s.Parent, 10)
+	}
+	return frame{}
+}
+
+// parseGoroutine parses a goroutine stack trace as printed
+// by [runtime.Stack]. Returns ok = false if the trace
+// has an unexpected format.
+func parseGoroutine(block string) (g goroutine, ok bool) {
+	lines := strings.Split(strings.TrimSpace(block), "\n")
+	if len(lines) < 3 {
+		return g, false
+	}
+
+	// The header is "goroutine 7 [chan receive]:".
+	header, found := strings.CutPrefix(lines[0], "goroutine ")
+	if !found {
+		return g, false
+	}
+	g.id, g.state, _ = strings.Cut(header, " ")
+	g.state = strings.Trim(g.state, "[]:")
+
+	// The rest are function/location pairs,
+	// with the "created by" pair at the end.
+	for i := 1; i+1 < len(lines); i += 2 {
+		f := frame{fn: lines[i], file: strings.TrimSpace(lines[i+1])}
+		f.file, _, _ = strings.Cut(f.file, " +0x")
+		if fn, ok := strings.CutPrefix(f.fn, "created by "); ok {
+			f.fn, g.parent, _ = strings.Cut(fn, " in goroutine ")
+			g.createdBy = f
+			break
+		}
+		// Strip the arguments.
+		if i := strings.LastIndex(f.fn, "("); i > 0 {
+			f.fn = f.fn[:i]
+		}
+		if g.top.fn == "" {
+			g.top = f
+		}
+		if g.origin.fn == "" && isUser(f.fn, f.file) {
+			g.origin = f
+		}
+	}
+	return g, g.createdBy.fn != ""
+}
+
+// isLibrary reports whether the function belongs to this library
+// (not counting the promisetest package itself).
+func isLibrary(fn string) bool {
+	if strings.HasPrefix(fn, selfPath+".") {
+		return false
+	}
+	rest, ok := strings.CutPrefix(fn, modulePath)
+	return ok && (strings.HasPrefix(rest, ".") || strings.HasPrefix(rest, "/"))
+}
+
+// isUser reports whether the function in the file is user code:
+// outside this library and the runtime, or in a test file.
+func isUser(fn, file string) bool {
+	if fn == "" || strings.HasPrefix(fn, "runtime.") {
+		return false
+	}
+	isTest := strings.HasSuffix(file, "_test.go") || strings.Contains(file, "_test.go:")
+	return !isLibrary(fn) || isTest
+}
+
+// stacks returns the stack traces of all goroutines.
+func stacks() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
@@ -0,0 +1,198 @@
+package promisetest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nalgeon/azor/promise"
+	"github.com/nalgeon/azor/promise/internal/site"
+)
+
+// cleanupT is a fakeT that collects cleanup functions
+// so that the test can run them on demand.
+type cleanupT struct {
+	fakeT
+	cleanups []func()
+}
+
+func (t *cleanupT) Cleanup(fn func()) {
+	t.cleanups = append(t.cleanups, fn)
+}
+
+func (t *cleanupT) runCleanups() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+func TestVerifyNoLeaks(t *testing.T) {
+	defer setTimeout(10 * time.Millisecond)()
+	t.Run("no leaks", func(t *testing.T) {
+		ft := &cleanupT{}
+		VerifyNoLeaks(ft)
+
+		p := promise.New(func(resolve func(any), reject func(error)) {
+			resolve(42)
+		}).Then(func(value any) any {
+			return value
+		})
+		<-p.Done()
+
+		ft.runCleanups()
+		ft.check(t, "")
+	})
+	t.Run("finish in time", func(t *testing.T) {
+		ft := &cleanupT{}
+		VerifyNoLeaks(ft)
+
+		promise.New(func(resolve func(any), reject func(error)) {
+			time.Sleep(time.Millisecond)
+			resolve(42)
+		})
+
+		ft.runCleanups()
+		ft.check(t, "")
+	})
+	t.Run("leaked executor", func(t *testing.T) {
+		ft := &cleanupT{}
+		VerifyNoLeaks(ft)
+
+		block := make(chan struct{})
+		defer close(block)
+		promise.New(func(resolve func(any), reject func(error)) {
+			<-block
+		})
+
+		ft.runCleanups()
+		ft.check(t, "leaked goroutines (1)")
+		msg := ft.msgs[0]
+		if !strings.Contains(msg, "created by github.com/nalgeon/azor/promise.New at") {
+			t.Errorf("want creation site in %q", msg)
+		}
+		if !strings.Contains(msg, "running github.com/nalgeon/azor/promise/promisetest.TestVerifyNoLeaks") {
+			t.Errorf("want executor in %q", msg)
+		}
+		if !strings.Contains(msg, "[chan receive]") {
+			t.Errorf("want goroutine state in %q", msg)
+		}
+	})
+	t.Run("leaked handler", func(t *testing.T) {
+		ft := &cleanupT{}
+		VerifyNoLeaks(ft)
+
+		p, resolve, _ := promise.WithResolvers()
+		defer resolve(nil)
+		p.Then(func(value any) any {
+			return value
+		})
+
+		ft.runCleanups()
+		ft.check(t, "leaked goroutines (1)")
+		// The parked goroutine has no user frames,
+		// but the promise creation site is recorded.
+		msg := ft.msgs[0]
+		if !strings.Contains(msg, "promise made in github.com/nalgeon/azor/promise/promisetest.TestVerifyNoLeaks") ||
+			!strings.Contains(msg, "leak_test.go:") {
+			t.Errorf("want Then call site in %q", msg)
+		}
+	})
+	t.Run("ignore existing", func(t *testing.T) {
+		block := make(chan struct{})
+		defer close(block)
+		promise.New(func(resolve func(any), reject func(error)) {
+			<-block
+		})
+
+		ft := &cleanupT{}
+		VerifyNoLeaks(ft)
+		ft.runCleanups()
+		ft.check(t, "")
+	})
+	t.Run("ignore foreign", func(t *testing.T) {
+		ft := &cleanupT{}
+		VerifyNoLeaks(ft)
+
+		block := make(chan struct{})
+		defer close(block)
+		go func() { <-block }()
+
+		ft.runCleanups()
+		ft.check(t, "")
+	})
+}
+
+func TestParseGoroutine(t *testing.T) {
+	block := `goroutine 6 [chan receive]:
+main.main.func1(0x10?, 0x563988?)
+	/src/main.go:3 +0x19
+github.com/nalgeon/azor/promise.New.func1()
+	/src/promise/promise.go:54 +0xec
+created by github.com/nalgeon/azor/promise.New in goroutine 1
+	/src/promise/promise.go:52 +0xc8`
+
+	g, ok := parseGoroutine(block)
+	if !ok {
+		t.Fatal("want ok")
+	}
+	if g.id != "6" {
+		t.Errorf("got id %q, want 6", g.id)
+	}
+	if g.parent != "1" {
+		t.Errorf("got parent %q, want 1", g.parent)
+	}
+	if g.state != "chan receive" {
+		t.Errorf("got state %q, want chan receive", g.state)
+	}
+	want := frame{"github.com/nalgeon/azor/promise.New", "/src/promise/promise.go:52"}
+	if g.createdBy != want {
+		t.Errorf("got created by %v, want %v", g.createdBy, want)
+	}
+	want = frame{"main.main.func1", "/src/main.go:3"}
+	if g.origin != want {
+		t.Errorf("got origin %v, want %v", g.origin, want)
+	}
+	if g.top != want {
+		t.Errorf("got top %v, want %v", g.top, want)
+	}
+}
+
+func TestMadeAt(t *testing.T) {
+	t.Run("recorded", func(t *testing.T) {
+		// The child promise was created by the library,
+		// so the site comes from the parent goroutine.
+		defer site.Set(1_000_001, site.Capture(0, 0))()
+		defer site.Set(1_000_002, &site.Site{Parent: 1_000_001})()
+		got := madeAt("1000002", nil)
+		if got.fn != "github.com/nalgeon/azor/promise/promisetest.TestMadeAt.func1" {
+			t.Errorf("got %v, want the test function", got)
+		}
+	})
+	t.Run("not recorded", func(t *testing.T) {
+		all := map[string]goroutine{
+			"1000005": {id: "1000005", parent: "1000004"},
+			"1000004": {id: "1000004", origin: frame{"main.main", "/src/main.go:3"}},
+		}
+		if got := madeAt("1000005", all); got != all["1000004"].origin {
+			t.Errorf("got %v, want the parent's origin", got)
+		}
+	})
+}
+
+func TestIsLibrary(t *testing.T) {
+	tests := []struct {
+		fn   string
+		want bool
+	}{
+		{"github.com/nalgeon/azor/promise.New", true},
+		{"github.com/nalgeon/azor.Run[...].func1", true},
+		{"github.com/nalgeon/azor_test.TestRun", false},
+		{"github.com/nalgeon/azor/promise/promisetest.RunFulfilled.func3", false},
+		{"main.main", false},
+	}
+	for _, test := range tests {
+		if got := isLibrary(test.fn); got != test.want {
+			t.Errorf("isLibrary(%q) = %v, want %v", test.fn, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+// Package promisetest provides utilities for testing code
+// that uses promises.
+//
+// The Run* harnesses run a test against promises in different
+// states (already settled, settled right away, settled later),
+// the same way the package's Promises/A+ test suite does.
+// The Assert* helpers check the outcome of a promise and report
+// a readable failure message if it does not match.
+package promisetest
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nalgeon/azor/promise"
+)
+
+// Timeout is how long the Assert* helpers wait
+// for a promise to settle before failing the test.
+var Timeout = time.Second
+
+// TestFunc is a test to run against a promise.
+// The test should call wg.Add before registering handlers
+// and wg.Done when each of them is finished.
+// The harness waits for wg before moving to the next case.
+type TestFunc func(t *testing.T, p *promise.Promise, wg *sync.WaitGroup)
+
+// RunFulfilled tests the behavior of a promise when it is fulfilled.
+// It runs the provided test function with 3 cases:
+//   - a promise that is already fulfilled,
+//   - a promise that is fulfilled immediately,
+//   - a promise that is fulfilled after a delay.
+func RunFulfilled(t *testing.T, value any, test TestFunc) {
+	t.Run("already fulfilled", func(t *testing.T) {
+		var wg sync.WaitGroup
+		test(t, promise.Resolve(value), &wg)
+		wg.Wait()
+	})
+	t.Run("fulfill immediately", func(t *testing.T) {
+		var wg sync.WaitGroup
+		p, resolve, _ := promise.WithResolvers()
+		test(t, p, &wg)
+		resolve(value)
+		wg.Wait()
+	})
+	t.Run("fulfill delayed", func(t *testing.T) {
+		var wg sync.WaitGroup
+		p, resolve, _ := promise.WithResolvers()
+		test(t, p, &wg)
+		go func() {
+			time.Sleep(time.Millisecond)
+			resolve(value)
+		}()
+		wg.Wait()
+	})
+}
+
+// RunRejected tests the behavior of a promise when it is rejected.
+// It runs the provided test function with 3 cases:
+//   - a promise that is already rejected,
+//   - a promise that is rejected immediately,
+//   - a promise that is rejected after a delay.
+func RunRejected(t *testing.T, err error, test TestFunc) {
+	t.Run("already rejected", func(t *testing.T) {
+		var wg sync.WaitGroup
+		test(t, promise.Reject(err), &wg)
+		wg.Wait()
+	})
+	t.Run("reject immediately", func(t *testing.T) {
+		var wg sync.WaitGroup
+		p, _, reject := promise.WithResolvers()
+		test(t, p, &wg)
+		reject(err)
+		wg.Wait()
+	})
+	t.Run("reject delayed", func(t *testing.T) {
+		var wg sync.WaitGroup
+		p, _, reject := promise.WithResolvers()
+		test(t, p, &wg)
+		go func() {
+			time.Sleep(time.Millisecond)
+			reject(err)
+		}()
+		wg.Wait()
+	})
+}
+
+// RunResolution tests the behavior of a promise when it is
+// resolved or rejected with a value created by newX function.
+// It runs the provided test function with 2 cases:
+//   - newX is returned from the onFulfilled handler,
+//   - newX is returned from the onRejected handler.
+func RunResolution(t *testing.T, newX func() *promise.Promise, test TestFunc) {
+	t.Run("from fulfilled", func(t *testing.T) {
+		var wg sync.WaitGroup
+		p := promise.Resolve(struct{}{}).Then(func(value any) any {
+			return newX()
+		})
+		test(t, p, &wg)
+		wg.Wait()
+	})
+	t.Run("from rejected", func(t *testing.T) {
+		var wg sync.WaitGroup
+		p := promise.Reject(errors.New("rejected")).Then(nil, func(err error) any {
+			return newX()
+		})
+		test(t, p, &wg)
+		wg.Wait()
+	})
+}
+
+// AssertFulfilled checks that the promise is fulfilled
+// with a value deeply equal to want. Waits up to [Timeout]
+// for the promise to settle.
+func AssertFulfilled(tb testing.TB, p *promise.Promise, want any) {
+	tb.Helper()
+	res, ok := wait(p, Timeout)
+	if !ok {
+		tb.Errorf("promise still pending after %v, want fulfilled with %#v", Timeout, want)
+		return
+	}
+	if res.err != nil {
+		tb.Errorf("promise rejected with %q, want fulfilled with %#v", res.err, want)
+		return
+	}
+	if !reflect.DeepEqual(res.val, want) {
+		tb.Errorf("promise fulfilled with %#v, want %#v", res.val, want)
+	}
+}
+
+// AssertRejected checks that the promise is rejected
+// with an error that matches target according to [errors.Is].
+// If target is nil, any error matches. Waits up to [Timeout]
+// for the promise to settle.
+func AssertRejected(tb testing.TB, p *promise.Promise, target error) {
+	tb.Helper()
+	want := "rejected"
+	if target != nil {
+		want = fmt.Sprintf("rejected with %q", target)
+	}
+	res, ok := wait(p, Timeout)
+	if !ok {
+		tb.Errorf("promise still pending after %v, want %s", Timeout, want)
+		return
+	}
+	if res.err == nil {
+		tb.Errorf("promise fulfilled with %#v, want %s", res.val, want)
+		return
+	}
+	if target != nil && !errors.Is(res.err, target) {
+		tb.Errorf("promise rejected with %q, want %s", res.err, want)
+	}
+}
+
+// AssertPending checks that the promise stays pending
+// for at least the given duration.
+func AssertPending(tb testing.TB, p *promise.Promise, d time.Duration) {
+	tb.Helper()
+	res, ok := wait(p, d)
+	if !ok {
+		return
+	}
+	if res.err != nil {
+		tb.Errorf("promise rejected with %q within %v, want pending", res.err, d)
+	} else {
+		tb.Errorf("promise fulfilled with %#v within %v, want pending", res.val, d)
+	}
+}
+
+// Eventually checks that the condition becomes true
+// within the given timeout. Calls cond periodically
+// until it returns true or the timeout expires.
+func Eventually(tb testing.TB, cond func() bool, timeout time.Duration) {
+	tb.Helper()
+	tick := max(timeout/100, time.Millisecond)
+	deadline := time.Now().Add(timeout)
+	for {
+		if cond() {
+			return
+		}
+		if time.Now().After(deadline) {
+			tb.Errorf("condition not met within %v", timeout)
+			return
+		}
+		time.Sleep(tick)
+	}
+}
+
+// result represents the result of a settled promise.
+type result struct {
+	val any
+	err error
+}
+
+// wait waits up to the given duration for the promise to settle
+// and returns its result. Returns ok = false if the promise
+// is still pending.
+func wait(p *promise.Promise, d time.Duration) (res result, ok bool) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-p.Done():
+	case <-timer.C:
+		return result{}, false
+	}
+
+	// The promise is settled, so the handlers
+	// receive its result right away.
+	np := p.Then(func(value any) any {
+		res.val = value
+		return nil
+	}, func(err error) any {
+		res.err = err
+		return nil
+	})
+	<-np.Done()
+	return res, true
+}
@@ -0,0 +1,211 @@
+package promisetest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nalgeon/azor/promise"
+)
+
+var errDummy = errors.New("dummy")
+
+// fakeT records test failures instead of reporting them.
+type fakeT struct {
+	testing.TB
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...any) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.msgs = append(t.msgs, fmt.Sprintf(format, args...))
+}
+
+// check verifies that the fake test failed with a message
+// containing want, or did not fail if want is empty.
+func (t *fakeT) check(tb testing.TB, want string) {
+	tb.Helper()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if want == "" {
+		if len(t.msgs) != 0 {
+			tb.Errorf("got failures %q, want none", t.msgs)
+		}
+		return
+	}
+	if len(t.msgs) != 1 {
+		tb.Errorf("got failures %q, want one", t.msgs)
+		return
+	}
+	if !strings.Contains(t.msgs[0], want) {
+		tb.Errorf("got failure %q, want %q", t.msgs[0], want)
+	}
+}
+
+func TestRunFulfilled(t *testing.T) {
+	var calls atomic.Int32
+	RunFulfilled(t, 42, func(t *testing.T, p *promise.Promise, wg *sync.WaitGroup) {
+		wg.Add(1)
+		p.Then(func(value any) any {
+			if value != 42 {
+				t.Errorf("got %v, want 42", value)
+			}
+			calls.Add(1)
+			wg.Done()
+			return nil
+		}, func(err error) any {
+			t.Errorf("got err %v, want nil", err)
+			wg.Done()
+			return nil
+		})
+	})
+	if calls.Load() != 3 {
+		t.Errorf("got %d calls, want 3", calls.Load())
+	}
+}
+
+func TestRunRejected(t *testing.T) {
+	var calls atomic.Int32
+	RunRejected(t, errDummy, func(t *testing.T, p *promise.Promise, wg *sync.WaitGroup) {
+		wg.Add(1)
+		p.Then(func(value any) any {
+			t.Errorf("got value %v, want error", value)
+			wg.Done()
+			return nil
+		}, func(err error) any {
+			if !errors.Is(err, errDummy) {
+				t.Errorf("got err %v, want %v", err, errDummy)
+			}
+			calls.Add(1)
+			wg.Done()
+			return nil
+		})
+	})
+	if calls.Load() != 3 {
+		t.Errorf("got %d calls, want 3", calls.Load())
+	}
+}
+
+func TestRunResolution(t *testing.T) {
+	var calls atomic.Int32
+	newX := func() *promise.Promise { return promise.Resolve("x") }
+	RunResolution(t, newX, func(t *testing.T, p *promise.Promise, wg *sync.WaitGroup) {
+		wg.Add(1)
+		p.Then(func(value any) any {
+			if value != "x" {
+				t.Errorf("got %v, want x", value)
+			}
+			calls.Add(1)
+			wg.Done()
+			return nil
+		})
+	})
+	if calls.Load() != 2 {
+		t.Errorf("got %d calls, want 2", calls.Load())
+	}
+}
+
+func TestAssertFulfilled(t *testing.T) {
+	defer setTimeout(10 * time.Millisecond)()
+	t.Run("fulfilled", func(t *testing.T) {
+		ft := &fakeT{}
+		AssertFulfilled(ft, promise.Resolve([]int{1, 2}), []int{1, 2})
+		ft.check(t, "")
+	})
+	t.Run("other value", func(t *testing.T) {
+		ft := &fakeT{}
+		AssertFulfilled(ft, promise.Resolve(11), 42)
+		ft.check(t, "promise fulfilled with 11, want 42")
+	})
+	t.Run("rejected", func(t *testing.T) {
+		ft := &fakeT{}
+		AssertFulfilled(ft, promise.Reject(errDummy), 42)
+		ft.check(t, `promise rejected with "dummy", want fulfilled with 42`)
+	})
+	t.Run("pending", func(t *testing.T) {
+		ft := &fakeT{}
+		p, _, _ := promise.WithResolvers()
+		AssertFulfilled(ft, p, 42)
+		ft.check(t, "promise still pending after 10ms, want fulfilled with 42")
+	})
+}
+
+func TestAssertRejected(t *testing.T) {
+	defer setTimeout(10 * time.Millisecond)()
+	t.Run("rejected", func(t *testing.T) {
+		ft := &fakeT{}
+		err := fmt.Errorf("wrapped: %w", errDummy)
+		AssertRejected(ft, promise.Reject(err), errDummy)
+		ft.check(t, "")
+	})
+	t.Run("any error", func(t *testing.T) {
+		ft := &fakeT{}
+		AssertRejected(ft, promise.Reject(errDummy), nil)
+		ft.check(t, "")
+	})
+	t.Run("other error", func(t *testing.T) {
+		ft := &fakeT{}
+		AssertRejected(ft, promise.Reject(errors.New("oops")), errDummy)
+		ft.check(t, `promise rejected with "oops", want rejected with "dummy"`)
+	})
+	t.Run("fulfilled", func(t *testing.T) {
+		ft := &fakeT{}
+		AssertRejected(ft, promise.Resolve("foo"), errDummy)
+		ft.check(t, `promise fulfilled with "foo", want rejected with "dummy"`)
+	})
+	t.Run("pending", func(t *testing.T) {
+		ft := &fakeT{}
+		p, _, _ := promise.WithResolvers()
+		AssertRejected(ft, p, nil)
+		ft.check(t, "promise still pending after 10ms, want rejected")
+	})
+}
+
+func TestAssertPending(t *testing.T) {
+	t.Run("pending", func(t *testing.T) {
+		ft := &fakeT{}
+		p, _, _ := promise.WithResolvers()
+		AssertPending(ft, p, 10*time.Millisecond)
+		ft.check(t, "")
+	})
+	t.Run("fulfilled", func(t *testing.T) {
+		ft := &fakeT{}
+		AssertPending(ft, promise.Resolve(42), 10*time.Millisecond)
+		ft.check(t, "promise fulfilled with 42 within 10ms, want pending")
+	})
+	t.Run("rejected", func(t *testing.T) {
+		ft := &fakeT{}
+		AssertPending(ft, promise.Reject(errDummy), 10*time.Millisecond)
+		ft.check(t, `promise rejected with "dummy" within 10ms, want pending`)
+	})
+}
+
+func TestEventually(t *testing.T) {
+	t.Run("met", func(t *testing.T) {
+		ft := &fakeT{}
+		var n atomic.Int32
+		Eventually(ft, func() bool { return n.Add(1) == 3 }, 100*time.Millisecond)
+		ft.check(t, "")
+	})
+	t.Run("not met", func(t *testing.T) {
+		ft := &fakeT{}
+		Eventually(ft, func() bool { return false }, 10*time.Millisecond)
+		ft.check(t, "condition not met within 10ms")
+	})
+}
+
+// setTimeout changes the Assert* timeout
+// and returns a function that restores it.
+func setTimeout(d time.Duration) func() {
+	prev := Timeout
+	Timeout = d
+	return func() { Timeout = prev }
+}
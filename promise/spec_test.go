@@ -23,6 +23,11 @@ var dummy = struct{ dummy string }{"dummy"}
 // 2.3.3.2: If retrieving the property `x.then` results in a thrown exception `e`, reject `promise` with `e` as the reason.
 // 2.3.3.3: If `then` is a function, call it with `x` as `this`, first argument `resolvePromise`, and second argument `rejectPromise`.
 
+// The testFulfilled, testRejected and testResolution harnesses are
+// mirrored by RunFulfilled, RunRejected and RunResolution in the
+// promisetest package, which can't be used here without an import
+// cycle. Keep the two copies in sync.
+
 // testFulfilled tests the behavior of a promise when it is fulfilled.
 // It runs the provided test function with 3 cases:
 //   - a promise that is already fulfilled,
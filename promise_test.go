@@ -137,6 +137,84 @@ func TestPromiseState(t *testing.T) {
 	})
 }
 
+func TestPromiseChan(t *testing.T) {
+	t.Run("fulfilled", func(t *testing.T) {
+		p := Run(func() (int, error) {
+			return 42, nil
+		})
+		res, ok := <-p.Chan()
+		if !ok {
+			t.Fatal("channel closed without result")
+		}
+		if res.Val != 42 || res.Err != nil {
+			t.Errorf("got %v, %v; want 42, nil", res.Val, res.Err)
+		}
+	})
+	t.Run("rejected", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		p := Run(func() (int, error) {
+			return 0, errFailed
+		})
+		res := <-p.Chan()
+		if !errors.Is(res.Err, errFailed) {
+			t.Errorf("got err = %v, want %v", res.Err, errFailed)
+		}
+	})
+	t.Run("select", func(t *testing.T) {
+		p := Run(func() (int, error) {
+			time.Sleep(50 * time.Millisecond)
+			return 42, nil
+		})
+		other := make(chan int, 1)
+		other <- 7
+		select {
+		case <-p.Chan():
+			t.Error("promise should not be settled")
+		case val := <-other:
+			if val != 7 {
+				t.Errorf("got %v, want 7", val)
+			}
+		}
+		if res := <-p.Chan(); res.Val != 42 {
+			t.Errorf("got %v, want 42", res.Val)
+		}
+	})
+	t.Run("closed", func(t *testing.T) {
+		p := Run(func() (int, error) {
+			return 42, nil
+		})
+		ch := p.Chan()
+		<-ch
+		if _, ok := <-ch; ok {
+			t.Error("channel should be closed")
+		}
+	})
+}
+
+func TestWithResolvers(t *testing.T) {
+	t.Run("resolve", func(t *testing.T) {
+		p, resolve, _ := WithResolvers[int]()
+		select {
+		case <-p.Done():
+			t.Error("promise should not be settled")
+		default:
+			// ok
+		}
+		resolve(42)
+		if val, err := p.Get(t.Context()); err != nil || val != 42 {
+			t.Errorf("got %v, %v; want 42, nil", val, err)
+		}
+	})
+	t.Run("reject", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		p, _, reject := WithResolvers[int]()
+		reject(errFailed)
+		if _, err := p.Get(t.Context()); !errors.Is(err, errFailed) {
+			t.Errorf("got err = %v, want %v", err, errFailed)
+		}
+	})
+}
+
 func TestRun(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		p := Run(func() (int, error) {
@@ -0,0 +1,243 @@
+package azor
+
+import (
+	"container/heap"
+	"context"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// QueueOrder is the order in which a [PriorityQueue] runs tasks.
+type QueueOrder int
+
+const (
+	// QueueByPriority runs the tasks with higher priority first.
+	QueueByPriority QueueOrder = iota
+	// QueueByDeadline runs the tasks with earlier context deadlines
+	// first (earliest deadline first). Tasks without a deadline run
+	// after the ones with a deadline. Tasks with the same deadline
+	// run in priority order.
+	QueueByDeadline
+)
+
+// ShutdownMode controls what [PriorityQueue.Shutdown]
+// does with the queued tasks.
+type ShutdownMode int
+
+const (
+	// ShutdownDrain runs the queued tasks before stopping the workers.
+	ShutdownDrain ShutdownMode = iota
+	// ShutdownDiscard rejects the queued tasks with [ErrClosed]
+	// and only waits for the running tasks.
+	ShutdownDiscard
+)
+
+// QueueConfig configures a [PriorityQueue].
+// Zero fields are replaced with the defaults.
+type QueueConfig struct {
+	// Workers is the number of tasks that run concurrently.
+	// Default is runtime.GOMAXPROCS(0).
+	Workers int
+
+	// Order is the order in which the tasks run.
+	// Default is [QueueByPriority].
+	Order QueueOrder
+}
+
+// PriorityQueue runs tasks on a fixed number of workers, picking
+// the most important queued task whenever a worker is free.
+// Tasks with the same priority (and deadline) run in the order
+// they were submitted.
+//
+// PriorityQueue is safe for concurrent use.
+type PriorityQueue[T any] struct {
+	mu     sync.Mutex
+	cond   *sync.Cond // signaled when a task is queued or the queue is closed
+	tasks  taskHeap[T]
+	seq    uint64 // number of submitted tasks
+	closed bool
+	wg     sync.WaitGroup // running workers
+}
+
+// queueTask is a task in a priority queue.
+type queueTask[T any] struct {
+	ctx      context.Context
+	fn       func(context.Context) (T, error)
+	priority int
+	deadline time.Time // zero if the context has no deadline
+	seq      uint64
+	index    int         // index in the heap, -1 if not in the heap
+	stop     func() bool // stops the cancellation callback
+	resolve  func(T)
+	reject   func(error)
+}
+
+// NewPriorityQueue creates a new priority queue and starts its workers.
+// Call [PriorityQueue.Shutdown] to stop them.
+func NewPriorityQueue[T any](cfg QueueConfig) *PriorityQueue[T] {
+	if cfg.Workers <= 0 {
+		cfg.Workers = runtime.GOMAXPROCS(0)
+	}
+	q := &PriorityQueue[T]{tasks: taskHeap[T]{byDeadline: cfg.Order == QueueByDeadline}}
+	q.cond = sync.NewCond(&q.mu)
+	q.wg.Add(cfg.Workers)
+	for range cfg.Workers {
+		go func() {
+			defer q.wg.Done()
+			q.work()
+		}()
+	}
+	return q
+}
+
+// Submit queues the function to run with the given priority.
+// Returns a [Promise] that resolves with the function's result.
+// The function receives the given context.
+//
+// If the context is canceled while the task is queued, the task
+// is removed from the queue, and the promise rejects with the
+// context's error. If the queue is shut down, the promise rejects
+// with [ErrClosed].
+//
+// Panics if the function is nil.
+func (q *PriorityQueue[T]) Submit(ctx context.Context, priority int, fn func(context.Context) (T, error)) *Promise[T] {
+	if fn == nil {
+		panic("azor: nil function")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := ctx.Err(); err != nil {
+		return rejected[T](context.Cause(ctx))
+	}
+
+	p, resolve, reject := WithResolvers[T]()
+	t := &queueTask[T]{ctx: ctx, fn: fn, priority: priority, resolve: resolve, reject: reject}
+	t.deadline, _ = ctx.Deadline()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return rejected[T](ErrClosed)
+	}
+	q.seq++
+	t.seq = q.seq
+	heap.Push(&q.tasks, t)
+	t.stop = context.AfterFunc(ctx, func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if t.index >= 0 {
+			heap.Remove(&q.tasks, t.index)
+			t.reject(context.Cause(ctx))
+		}
+	})
+	q.cond.Signal()
+	return p
+}
+
+// Len returns the number of queued tasks
+// (not including the running ones).
+func (q *PriorityQueue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.tasks.Len()
+}
+
+// Shutdown stops accepting new tasks and stops the workers after
+// the queued tasks are handled according to the mode. Returns
+// a [Promise] that resolves when all the workers have stopped.
+// Shutdown is safe to call multiple times.
+func (q *PriorityQueue[T]) Shutdown(mode ShutdownMode) *Promise[struct{}] {
+	q.mu.Lock()
+	q.closed = true
+	if mode == ShutdownDiscard {
+		for q.tasks.Len() > 0 {
+			t := heap.Pop(&q.tasks).(*queueTask[T])
+			t.stop()
+			t.reject(ErrClosed)
+		}
+	}
+	q.cond.Broadcast()
+	q.mu.Unlock()
+
+	return Run(func() (struct{}, error) {
+		q.wg.Wait()
+		return struct{}{}, nil
+	})
+}
+
+// work runs the queued tasks until the queue
+// is closed and empty.
+func (q *PriorityQueue[T]) work() {
+	for {
+		q.mu.Lock()
+		for q.tasks.Len() == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if q.tasks.Len() == 0 {
+			q.mu.Unlock()
+			return
+		}
+		t := heap.Pop(&q.tasks).(*queueTask[T])
+		q.mu.Unlock()
+
+		t.stop()
+		val, err := try(func() (T, error) { return t.fn(t.ctx) })
+		if err != nil {
+			t.reject(err)
+		} else {
+			t.resolve(val)
+		}
+	}
+}
+
+// taskHeap is a heap of queued tasks,
+// with the task to run next at the top.
+type taskHeap[T any] struct {
+	items      []*queueTask[T]
+	byDeadline bool
+}
+
+func (h *taskHeap[T]) Len() int {
+	return len(h.items)
+}
+
+func (h *taskHeap[T]) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	if h.byDeadline && !a.deadline.Equal(b.deadline) {
+		switch {
+		case a.deadline.IsZero():
+			return false
+		case b.deadline.IsZero():
+			return true
+		default:
+			return a.deadline.Before(b.deadline)
+		}
+	}
+	if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	return a.seq < b.seq
+}
+
+func (h *taskHeap[T]) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.items[i].index = i
+	h.items[j].index = j
+}
+
+func (h *taskHeap[T]) Push(x any) {
+	t := x.(*queueTask[T])
+	t.index = len(h.items)
+	h.items = append(h.items, t)
+}
+
+func (h *taskHeap[T]) Pop() any {
+	n := len(h.items)
+	t := h.items[n-1]
+	h.items[n-1] = nil
+	h.items = h.items[:n-1]
+	t.index = -1
+	return t
+}
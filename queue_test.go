@@ -0,0 +1,190 @@
+package azor
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder returns a task function that appends
+// the name to the list of names when called.
+func recorder(mu *sync.Mutex, names *[]string, name string) func(context.Context) (string, error) {
+	return func(context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		*names = append(*names, name)
+		return name, nil
+	}
+}
+
+// blockQueue occupies the queue's only worker until
+// the returned function is called.
+func blockQueue(q *PriorityQueue[string]) func() {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	q.Submit(context.Background(), 0, func(context.Context) (string, error) {
+		close(started)
+		<-release
+		return "", nil
+	})
+	<-started
+	return func() { close(release) }
+}
+
+func TestPriorityQueue(t *testing.T) {
+	t.Run("priority", func(t *testing.T) {
+		q := NewPriorityQueue[string](QueueConfig{Workers: 1})
+		release := blockQueue(q)
+		var mu sync.Mutex
+		var names []string
+		ctx := context.Background()
+		q.Submit(ctx, 1, recorder(&mu, &names, "low"))
+		q.Submit(ctx, 5, recorder(&mu, &names, "high-1"))
+		q.Submit(ctx, 3, recorder(&mu, &names, "mid"))
+		p := q.Submit(ctx, 5, recorder(&mu, &names, "high-2"))
+		if n := q.Len(); n != 4 {
+			t.Errorf("got %d queued, want 4", n)
+		}
+		release()
+		if val, err := p.Get(ctx); val != "high-2" || err != nil {
+			t.Errorf("got %q, %v; want high-2, nil", val, err)
+		}
+		q.Shutdown(ShutdownDrain).Get(ctx)
+		want := []string{"high-1", "high-2", "mid", "low"}
+		if !slices.Equal(names, want) {
+			t.Errorf("got %v, want %v", names, want)
+		}
+	})
+	t.Run("deadline", func(t *testing.T) {
+		q := NewPriorityQueue[string](QueueConfig{Workers: 1, Order: QueueByDeadline})
+		release := blockQueue(q)
+		var mu sync.Mutex
+		var names []string
+		now := time.Now()
+		deadline := func(d time.Duration) context.Context {
+			ctx, cancel := context.WithDeadline(context.Background(), now.Add(d))
+			t.Cleanup(cancel)
+			return ctx
+		}
+		q.Submit(context.Background(), 10, recorder(&mu, &names, "none"))
+		q.Submit(deadline(3*time.Second), 0, recorder(&mu, &names, "3s"))
+		q.Submit(deadline(time.Second), 0, recorder(&mu, &names, "1s-low"))
+		q.Submit(deadline(time.Second), 1, recorder(&mu, &names, "1s-high"))
+		release()
+		q.Shutdown(ShutdownDrain).Get(context.Background())
+		want := []string{"1s-high", "1s-low", "3s", "none"}
+		if !slices.Equal(names, want) {
+			t.Errorf("got %v, want %v", names, want)
+		}
+	})
+	t.Run("workers", func(t *testing.T) {
+		q := NewPriorityQueue[int](QueueConfig{Workers: 3})
+		var mu sync.Mutex
+		running, maxRunning := 0, 0
+		var ps []*Promise[int]
+		for i := range 10 {
+			ps = append(ps, q.Submit(context.Background(), 0, func(context.Context) (int, error) {
+				mu.Lock()
+				running++
+				maxRunning = max(maxRunning, running)
+				mu.Unlock()
+				time.Sleep(5 * time.Millisecond)
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return i, nil
+			}))
+		}
+		for i, p := range ps {
+			if val, _ := p.Get(context.Background()); val != i {
+				t.Errorf("#%d: got %d, want %d", i, val, i)
+			}
+		}
+		if maxRunning != 3 {
+			t.Errorf("got %d max running, want 3", maxRunning)
+		}
+		q.Shutdown(ShutdownDrain)
+	})
+	t.Run("cancel queued", func(t *testing.T) {
+		q := NewPriorityQueue[string](QueueConfig{Workers: 1})
+		release := blockQueue(q)
+		ctx, cancel := context.WithCancel(context.Background())
+		p := q.Submit(ctx, 0, func(context.Context) (string, error) {
+			t.Error("canceled task should not run")
+			return "", nil
+		})
+		cancel()
+		if _, err := p.Get(context.Background()); !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want context.Canceled", err)
+		}
+		if n := q.Len(); n != 0 {
+			t.Errorf("got %d queued, want 0", n)
+		}
+		release()
+		q.Shutdown(ShutdownDrain).Get(context.Background())
+	})
+	t.Run("error and panic", func(t *testing.T) {
+		q := NewPriorityQueue[int](QueueConfig{Workers: 1})
+		defer q.Shutdown(ShutdownDrain)
+		p1 := q.Submit(context.Background(), 0, func(context.Context) (int, error) {
+			return 0, errDummy
+		})
+		p2 := q.Submit(context.Background(), 0, func(context.Context) (int, error) {
+			panic("boom")
+		})
+		if _, err := p1.Get(context.Background()); !errors.Is(err, errDummy) {
+			t.Errorf("got %v, want %v", err, errDummy)
+		}
+		if _, err := p2.Get(context.Background()); err == nil {
+			t.Error("want error")
+		}
+	})
+	t.Run("shutdown drain", func(t *testing.T) {
+		q := NewPriorityQueue[string](QueueConfig{Workers: 1})
+		release := blockQueue(q)
+		var mu sync.Mutex
+		var names []string
+		p := q.Submit(context.Background(), 0, recorder(&mu, &names, "queued"))
+		done := q.Shutdown(ShutdownDrain)
+		if _, err := q.Submit(context.Background(), 0, recorder(&mu, &names, "late")).Get(context.Background()); !errors.Is(err, ErrClosed) {
+			t.Errorf("got %v, want ErrClosed", err)
+		}
+		release()
+		done.Get(context.Background())
+		if val, err := p.Get(context.Background()); val != "queued" || err != nil {
+			t.Errorf("got %q, %v; want queued, nil", val, err)
+		}
+	})
+	t.Run("shutdown discard", func(t *testing.T) {
+		q := NewPriorityQueue[string](QueueConfig{Workers: 1})
+		release := blockQueue(q)
+		p := q.Submit(context.Background(), 0, func(context.Context) (string, error) {
+			t.Error("discarded task should not run")
+			return "", nil
+		})
+		done := q.Shutdown(ShutdownDiscard)
+		if _, err := p.Get(context.Background()); !errors.Is(err, ErrClosed) {
+			t.Errorf("got %v, want ErrClosed", err)
+		}
+		select {
+		case <-done.Done():
+			t.Error("shutdown should wait for the running task")
+		case <-time.After(10 * time.Millisecond):
+		}
+		release()
+		done.Get(context.Background())
+	})
+	t.Run("nil function", func(t *testing.T) {
+		q := NewPriorityQueue[int](QueueConfig{Workers: 1})
+		defer q.Shutdown(ShutdownDrain)
+		defer func() {
+			if recover() == nil {
+				t.Error("want panic")
+			}
+		}()
+		q.Submit(context.Background(), 0, nil)
+	})
+}
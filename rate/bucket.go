@@ -0,0 +1,86 @@
+package rate
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nalgeon/azor"
+)
+
+// TokenBucket is a token bucket rate limiter. The bucket holds up
+// to burst tokens and refills at a constant rate. Each event takes
+// tokens from the bucket, so the limiter allows bursts of up to
+// burst events, and the given rate on average.
+//
+// Reservations are served in FIFO order: a later reservation
+// never gets its tokens before an earlier one.
+//
+// TokenBucket is safe for concurrent use.
+type TokenBucket struct {
+	rate  float64 // tokens per second
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64   // can be negative when tokens are reserved ahead
+	last   time.Time // when tokens were last updated
+}
+
+// NewTokenBucket creates a new token bucket that refills at the
+// given rate (tokens per second) and holds up to burst tokens.
+// The bucket starts full.
+// Panics if the rate or burst is not positive.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if rate <= 0 || burst <= 0 {
+		panic("rate: rate and burst must be positive")
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Reserve reserves n tokens. See [Limiter.Reserve] for details.
+// Rejects with [ErrExceedsLimit] if n exceeds the burst.
+func (b *TokenBucket) Reserve(ctx context.Context, n int) *azor.Promise[struct{}] {
+	if p := check(n, b.burst); p != nil {
+		return p
+	}
+
+	b.mu.Lock()
+	now := time.Now()
+	b.advance(now)
+	b.tokens -= float64(n)
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	return schedule(ctx, now.Add(wait), func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.advance(time.Now())
+		b.tokens = min(b.tokens+float64(n), b.burst)
+	})
+}
+
+// Tokens returns the number of tokens currently available.
+// It's negative if tokens are reserved ahead.
+func (b *TokenBucket) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance(time.Now())
+	return b.tokens
+}
+
+// advance refills the bucket for the time passed since the last update.
+// Must be called with the mutex held.
+func (b *TokenBucket) advance(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(b.tokens+elapsed.Seconds()*b.rate, b.burst)
+		b.last = now
+	}
+}
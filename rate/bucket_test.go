@@ -0,0 +1,96 @@
+package rate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	t.Run("burst", func(t *testing.T) {
+		b := NewTokenBucket(10, 3)
+		for i := range 3 {
+			if d, err := elapsed(b.Reserve(context.Background(), 1)); err != nil || d > 10*time.Millisecond {
+				t.Errorf("#%d: got %v, %v; want immediate resolve", i, d, err)
+			}
+		}
+	})
+	t.Run("wait", func(t *testing.T) {
+		b := NewTokenBucket(50, 1)
+		b.Reserve(context.Background(), 1)
+		// The next token comes in 20ms.
+		if d, err := elapsed(b.Reserve(context.Background(), 1)); err != nil || d < 15*time.Millisecond {
+			t.Errorf("got %v, %v; want resolve after 20ms", d, err)
+		}
+	})
+	t.Run("fifo", func(t *testing.T) {
+		b := NewTokenBucket(50, 2)
+		b.Reserve(context.Background(), 2)
+		p1 := b.Reserve(context.Background(), 2)
+		p2 := b.Reserve(context.Background(), 1)
+		// p1 waits 40ms, p2 waits another 20ms after it.
+		d1, _ := elapsed(p1)
+		if d2, _ := elapsed(p2); d2 < 15*time.Millisecond {
+			t.Errorf("got %v after p1 (%v), want at least 20ms", d2, d1)
+		}
+	})
+	t.Run("exceeds limit", func(t *testing.T) {
+		b := NewTokenBucket(10, 3)
+		if _, err := elapsed(b.Reserve(context.Background(), 4)); !errors.Is(err, ErrExceedsLimit) {
+			t.Errorf("got %v, want ErrExceedsLimit", err)
+		}
+	})
+	t.Run("deadline", func(t *testing.T) {
+		b := NewTokenBucket(1, 1)
+		b.Reserve(context.Background(), 1)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := elapsed(b.Reserve(ctx, 1)); !errors.Is(err, ErrDeadline) {
+			t.Errorf("got %v, want ErrDeadline", err)
+		}
+		// The tokens are not reserved.
+		if tokens := b.Tokens(); tokens < 0 {
+			t.Errorf("got %v tokens, want non-negative", tokens)
+		}
+	})
+	t.Run("cancel returns tokens", func(t *testing.T) {
+		b := NewTokenBucket(1, 2)
+		b.Reserve(context.Background(), 2)
+		ctx, cancel := context.WithCancel(context.Background())
+		p := b.Reserve(ctx, 2)
+		if tokens := b.Tokens(); tokens > -1.9 {
+			t.Errorf("got %v tokens, want about -2", tokens)
+		}
+		cancel()
+		if _, err := elapsed(p); !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want context.Canceled", err)
+		}
+		if tokens := b.Tokens(); tokens < -0.1 {
+			t.Errorf("got %v tokens, want about 0", tokens)
+		}
+	})
+	t.Run("zero tokens", func(t *testing.T) {
+		b := NewTokenBucket(10, 1)
+		b.Reserve(context.Background(), 1)
+		if d, err := elapsed(b.Reserve(context.Background(), 0)); err != nil || d > 10*time.Millisecond {
+			t.Errorf("got %v, %v; want immediate resolve", d, err)
+		}
+	})
+	t.Run("negative tokens", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("want panic")
+			}
+		}()
+		NewTokenBucket(10, 1).Reserve(context.Background(), -1)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("want panic")
+			}
+		}()
+		NewTokenBucket(0, 1)
+	})
+}
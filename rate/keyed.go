@@ -0,0 +1,96 @@
+package rate
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nalgeon/azor"
+)
+
+// Keyed is a set of limiters, one per key (such as a tenant or
+// a client IP). It creates limiters on demand and evicts the ones
+// that have not been used for the idle duration.
+//
+// Keyed is safe for concurrent use.
+type Keyed[K comparable] struct {
+	newLimiter func(K) Limiter
+	idle       time.Duration
+
+	mu       sync.Mutex
+	limiters map[K]*keyedLimiter
+	swept    time.Time // when idle limiters were last evicted
+}
+
+// keyedLimiter is a limiter with its last use time.
+type keyedLimiter struct {
+	Limiter
+	used time.Time
+}
+
+// NewKeyed creates a new set of limiters that creates a limiter for
+// a key with the newLimiter function when the key is first used.
+// Limiters not used for the idle duration are evicted, so the next
+// use of their key creates a new one. If idle is zero, limiters
+// are never evicted.
+//
+// The idle duration should be longer than the time it takes
+// an evicted limiter to recover its full capacity, so that
+// eviction does not let extra events through.
+//
+// Panics if the function is nil.
+func NewKeyed[K comparable](newLimiter func(K) Limiter, idle time.Duration) *Keyed[K] {
+	if newLimiter == nil {
+		panic("rate: nil function")
+	}
+	return &Keyed[K]{
+		newLimiter: newLimiter,
+		idle:       idle,
+		limiters:   make(map[K]*keyedLimiter),
+		swept:      time.Now(),
+	}
+}
+
+// Reserve reserves n tokens from the key's limiter.
+// See [Limiter.Reserve] for details.
+func (k *Keyed[K]) Reserve(ctx context.Context, key K, n int) *azor.Promise[struct{}] {
+	return k.Get(key).Reserve(ctx, n)
+}
+
+// Get returns the key's limiter, creating it if necessary.
+func (k *Keyed[K]) Get(key K) Limiter {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	now := time.Now()
+	k.sweep(now)
+	l, ok := k.limiters[key]
+	if !ok {
+		l = &keyedLimiter{Limiter: k.newLimiter(key)}
+		k.limiters[key] = l
+	}
+	l.used = now
+	return l.Limiter
+}
+
+// Len returns the number of limiters in the set.
+func (k *Keyed[K]) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.sweep(time.Now())
+	return len(k.limiters)
+}
+
+// sweep evicts the idle limiters, at most
+// once per idle duration.
+// Must be called with the mutex held.
+func (k *Keyed[K]) sweep(now time.Time) {
+	if k.idle <= 0 || now.Sub(k.swept) < k.idle {
+		return
+	}
+	k.swept = now
+	for key, l := range k.limiters {
+		if now.Sub(l.used) >= k.idle {
+			delete(k.limiters, key)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package rate
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestKeyed(t *testing.T) {
+	t.Run("per key", func(t *testing.T) {
+		k := NewKeyed(func(string) Limiter { return NewTokenBucket(1, 1) }, 0)
+		for _, key := range []string{"alice", "bob"} {
+			if d, err := elapsed(k.Reserve(context.Background(), key, 1)); err != nil || d > 10*time.Millisecond {
+				t.Errorf("%s: got %v, %v; want immediate resolve", key, d, err)
+			}
+		}
+		if k.Get("alice") != k.Get("alice") {
+			t.Error("want the same limiter for the same key")
+		}
+		if n := k.Len(); n != 2 {
+			t.Errorf("got %d limiters, want 2", n)
+		}
+	})
+	t.Run("idle eviction", func(t *testing.T) {
+		created := 0
+		k := NewKeyed(func(string) Limiter {
+			created++
+			return NewTokenBucket(1, 1)
+		}, 20*time.Millisecond)
+		k.Get("alice")
+		time.Sleep(10 * time.Millisecond)
+		k.Get("bob")
+		time.Sleep(15 * time.Millisecond)
+		// alice is idle for 25ms, bob for 15ms.
+		if n := k.Len(); n != 1 {
+			t.Errorf("got %d limiters, want 1", n)
+		}
+		k.Get("alice")
+		if created != 3 {
+			t.Errorf("got %d limiters created, want 3", created)
+		}
+	})
+	t.Run("nil function", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("want panic")
+			}
+		}()
+		NewKeyed[string](nil, 0)
+	})
+}
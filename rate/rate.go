@@ -0,0 +1,115 @@
+// Package rate provides rate limiters whose reservations return promises
+// instead of blocking: [TokenBucket] and [SlidingWindow], along with
+// per-key limiters ([Keyed]) for multi-tenant APIs.
+package rate
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nalgeon/azor"
+)
+
+// ErrDeadline is the error that [Limiter.Reserve] rejects with when
+// the tokens would not become available before the context's deadline.
+var ErrDeadline = errors.New("rate: wait would exceed context deadline")
+
+// ErrExceedsLimit is the error that [Limiter.Reserve] rejects with
+// when the number of tokens requested exceeds the limiter's capacity,
+// so the reservation can never succeed.
+var ErrExceedsLimit = errors.New("rate: tokens exceed limit")
+
+// Limiter controls how frequently events are allowed to happen.
+type Limiter interface {
+	// Reserve reserves n tokens. Returns a promise that resolves when
+	// the tokens become available. If the context's deadline comes
+	// before that, the promise rejects right away with [ErrDeadline]
+	// and reserves nothing. If the context is canceled while waiting,
+	// the promise rejects with the context's error, and the tokens
+	// are returned to the limiter. Reserving zero tokens resolves
+	// right away. Panics if n is negative.
+	Reserve(ctx context.Context, n int) *azor.Promise[struct{}]
+}
+
+// Limit returns an asynchronous function that calls fn after
+// reserving a token from the limiter, so that the calls respect
+// the limiter's rate.
+//
+// Panics if the function is nil.
+func Limit[T any](l Limiter, fn azor.AsyncFunc[T]) azor.AsyncFunc[T] {
+	if fn == nil {
+		panic("rate: nil function")
+	}
+	return func() *azor.Promise[T] {
+		return azor.Run(func() (T, error) {
+			if _, err := l.Reserve(context.Background(), 1).Get(context.Background()); err != nil {
+				var zero T
+				return zero, err
+			}
+			return fn().Get(context.Background())
+		})
+	}
+}
+
+// schedule returns a promise that resolves at the given time.
+// If the context's deadline is before that time, calls cancel
+// and rejects with ErrDeadline. If the context is canceled while
+// waiting, calls cancel and rejects with the context's error.
+func schedule(ctx context.Context, at time.Time, cancel func()) *azor.Promise[struct{}] {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	p, resolve, reject := azor.WithResolvers[struct{}]()
+	if err := ctx.Err(); err != nil {
+		cancel()
+		reject(context.Cause(ctx))
+		return p
+	}
+	wait := time.Until(at)
+	if wait <= 0 {
+		resolve(struct{}{})
+		return p
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(at) {
+		cancel()
+		reject(ErrDeadline)
+		return p
+	}
+
+	var stop func() bool
+	ready := make(chan struct{}) // closed when stop is set
+	timer := time.AfterFunc(wait, func() {
+		<-ready
+		stop()
+		resolve(struct{}{})
+	})
+	stop = context.AfterFunc(ctx, func() {
+		if timer.Stop() {
+			cancel()
+			reject(context.Cause(ctx))
+		}
+	})
+	close(ready)
+	return p
+}
+
+// check handles the reservations that don't depend on the limiter's
+// state: resolves right away for zero tokens, and rejects with
+// ErrExceedsLimit if n exceeds the limit. Returns nil otherwise.
+// Panics if n is negative.
+func check(n int, limit float64) *azor.Promise[struct{}] {
+	if n < 0 {
+		panic("rate: negative tokens")
+	}
+	if n > 0 && float64(n) <= limit {
+		return nil
+	}
+	p, resolve, reject := azor.WithResolvers[struct{}]()
+	if n == 0 {
+		resolve(struct{}{})
+	} else {
+		reject(ErrExceedsLimit)
+	}
+	return p
+}
@@ -0,0 +1,115 @@
+package rate
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nalgeon/azor"
+)
+
+// elapsed returns how long it takes for the promise to settle,
+// and the promise's error.
+func elapsed(p *azor.Promise[struct{}]) (time.Duration, error) {
+	start := time.Now()
+	_, err := p.Get(context.Background())
+	return time.Since(start), err
+}
+
+func TestSchedule(t *testing.T) {
+	t.Run("past", func(t *testing.T) {
+		p := schedule(context.Background(), time.Now().Add(-time.Second), func() {
+			t.Error("cancel should not be called")
+		})
+		if d, err := elapsed(p); err != nil || d > 10*time.Millisecond {
+			t.Errorf("got %v, %v; want immediate resolve", d, err)
+		}
+	})
+	t.Run("future", func(t *testing.T) {
+		p := schedule(context.Background(), time.Now().Add(30*time.Millisecond), func() {
+			t.Error("cancel should not be called")
+		})
+		if d, err := elapsed(p); err != nil || d < 25*time.Millisecond {
+			t.Errorf("got %v, %v; want resolve after 30ms", d, err)
+		}
+	})
+	t.Run("deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		var canceled atomic.Bool
+		p := schedule(ctx, time.Now().Add(time.Second), func() { canceled.Store(true) })
+		if d, err := elapsed(p); !errors.Is(err, ErrDeadline) || d > 5*time.Millisecond {
+			t.Errorf("got %v, %v; want immediate ErrDeadline", d, err)
+		}
+		if !canceled.Load() {
+			t.Error("want cancel called")
+		}
+	})
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var canceled atomic.Bool
+		p := schedule(ctx, time.Now().Add(time.Second), func() { canceled.Store(true) })
+		time.AfterFunc(10*time.Millisecond, cancel)
+		if _, err := elapsed(p); !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want context.Canceled", err)
+		}
+		if !canceled.Load() {
+			t.Error("want cancel called")
+		}
+	})
+	t.Run("already canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		var canceled atomic.Bool
+		p := schedule(ctx, time.Now(), func() { canceled.Store(true) })
+		if _, err := elapsed(p); !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want context.Canceled", err)
+		}
+		if !canceled.Load() {
+			t.Error("want cancel called")
+		}
+	})
+}
+
+func TestLimit(t *testing.T) {
+	t.Run("rate", func(t *testing.T) {
+		b := NewTokenBucket(100, 1)
+		var calls atomic.Int32
+		fn := Limit(b, azor.Async(func() (int, error) {
+			return int(calls.Add(1)), nil
+		}))
+		start := time.Now()
+		ps := []*azor.Promise[int]{fn(), fn(), fn(), fn()}
+		for _, p := range ps {
+			if _, err := p.Get(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		// 1 token right away, then 3 more at 10ms each.
+		if d := time.Since(start); d < 25*time.Millisecond {
+			t.Errorf("got %v, want at least 30ms", d)
+		}
+		if n := calls.Load(); n != 4 {
+			t.Errorf("got %d calls, want 4", n)
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		fn := Limit(NewTokenBucket(100, 1), azor.Async(func() (int, error) {
+			return 0, errBoom
+		}))
+		if _, err := fn().Get(context.Background()); !errors.Is(err, errBoom) {
+			t.Errorf("got %v, want %v", err, errBoom)
+		}
+	})
+	t.Run("nil function", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("want panic")
+			}
+		}()
+		Limit[int](NewTokenBucket(1, 1), nil)
+	})
+}
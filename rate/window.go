@@ -0,0 +1,112 @@
+package rate
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nalgeon/azor"
+)
+
+// SlidingWindow is a sliding window rate limiter. It allows up to
+// limit tokens within any window of the given duration, based on the
+// exact times of the previous reservations (a sliding log).
+//
+// Reservations are served in FIFO order: a later reservation
+// never gets its tokens before an earlier one.
+//
+// SlidingWindow is safe for concurrent use.
+type SlidingWindow struct {
+	limit  int
+	window time.Duration
+
+	mu      sync.Mutex
+	entries []*entry // reservations, ordered by time
+}
+
+// entry is a reservation of n tokens at the given time.
+type entry struct {
+	at time.Time
+	n  int
+}
+
+// NewSlidingWindow creates a new sliding window limiter that allows
+// up to limit tokens within any window of the given duration.
+// Panics if the limit or window is not positive.
+func NewSlidingWindow(limit int, window time.Duration) *SlidingWindow {
+	if limit <= 0 || window <= 0 {
+		panic("rate: limit and window must be positive")
+	}
+	return &SlidingWindow{limit: limit, window: window}
+}
+
+// Reserve reserves n tokens. See [Limiter.Reserve] for details.
+// Rejects with [ErrExceedsLimit] if n exceeds the limit.
+func (w *SlidingWindow) Reserve(ctx context.Context, n int) *azor.Promise[struct{}] {
+	if p := check(n, float64(w.limit)); p != nil {
+		return p
+	}
+
+	w.mu.Lock()
+	now := time.Now()
+	w.prune(now)
+	e := &entry{at: w.next(now, n), n: n}
+	w.entries = append(w.entries, e)
+	w.mu.Unlock()
+
+	return schedule(ctx, e.at, func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if i := slices.Index(w.entries, e); i >= 0 {
+			w.entries = slices.Delete(w.entries, i, i+1)
+		}
+	})
+}
+
+// next returns the earliest time (not before the last reservation)
+// when n more tokens fit into the window.
+// Must be called with the mutex held.
+func (w *SlidingWindow) next(now time.Time, n int) time.Time {
+	at := now
+	if len(w.entries) > 0 {
+		at = later(at, w.entries[len(w.entries)-1].at)
+	}
+	for {
+		// Count the tokens within the window ending at the given time.
+		// The first entry inside the window is the one to wait for
+		// if the tokens don't fit.
+		used, first := 0, -1
+		for i, e := range w.entries {
+			if e.at.After(at.Add(-w.window)) {
+				used += e.n
+				if first < 0 {
+					first = i
+				}
+			}
+		}
+		if used+n <= w.limit {
+			return at
+		}
+		at = w.entries[first].at.Add(w.window)
+	}
+}
+
+// prune removes the entries that are outside the window.
+// Must be called with the mutex held.
+func (w *SlidingWindow) prune(now time.Time) {
+	cutoff := now.Add(-w.window)
+	i := 0
+	for i < len(w.entries) && !w.entries[i].at.After(cutoff) {
+		i++
+	}
+	w.entries = slices.Delete(w.entries, 0, i)
+}
+
+// later returns the later of the two times.
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
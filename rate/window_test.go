@@ -0,0 +1,97 @@
+package rate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSlidingWindow(t *testing.T) {
+	t.Run("within limit", func(t *testing.T) {
+		w := NewSlidingWindow(3, time.Second)
+		for i := range 3 {
+			if d, err := elapsed(w.Reserve(context.Background(), 1)); err != nil || d > 10*time.Millisecond {
+				t.Errorf("#%d: got %v, %v; want immediate resolve", i, d, err)
+			}
+		}
+	})
+	t.Run("wait", func(t *testing.T) {
+		w := NewSlidingWindow(2, 30*time.Millisecond)
+		w.Reserve(context.Background(), 1)
+		w.Reserve(context.Background(), 1)
+		// The window slides past the first reservation in 30ms.
+		if d, err := elapsed(w.Reserve(context.Background(), 1)); err != nil || d < 25*time.Millisecond {
+			t.Errorf("got %v, %v; want resolve after 30ms", d, err)
+		}
+	})
+	t.Run("sliding", func(t *testing.T) {
+		w := NewSlidingWindow(2, 40*time.Millisecond)
+		w.Reserve(context.Background(), 1)
+		time.Sleep(20 * time.Millisecond)
+		w.Reserve(context.Background(), 1)
+		// Waits for the first reservation to leave the window (~20ms),
+		// not for the whole window.
+		d, err := elapsed(w.Reserve(context.Background(), 1))
+		if err != nil || d < 15*time.Millisecond || d > 35*time.Millisecond {
+			t.Errorf("got %v, %v; want resolve after about 20ms", d, err)
+		}
+	})
+	t.Run("exceeds limit", func(t *testing.T) {
+		w := NewSlidingWindow(3, time.Second)
+		if _, err := elapsed(w.Reserve(context.Background(), 4)); !errors.Is(err, ErrExceedsLimit) {
+			t.Errorf("got %v, want ErrExceedsLimit", err)
+		}
+	})
+	t.Run("deadline", func(t *testing.T) {
+		w := NewSlidingWindow(1, time.Second)
+		w.Reserve(context.Background(), 1)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := elapsed(w.Reserve(ctx, 1)); !errors.Is(err, ErrDeadline) {
+			t.Errorf("got %v, want ErrDeadline", err)
+		}
+		if n := len(w.entries); n != 1 {
+			t.Errorf("got %d entries, want 1", n)
+		}
+	})
+	t.Run("cancel returns tokens", func(t *testing.T) {
+		w := NewSlidingWindow(1, 30*time.Millisecond)
+		w.Reserve(context.Background(), 1)
+		ctx, cancel := context.WithCancel(context.Background())
+		p := w.Reserve(ctx, 1)
+		cancel()
+		if _, err := elapsed(p); !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want context.Canceled", err)
+		}
+		// The canceled reservation does not delay the next one
+		// beyond the first window.
+		d, err := elapsed(w.Reserve(context.Background(), 1))
+		if err != nil || d > 40*time.Millisecond {
+			t.Errorf("got %v, %v; want resolve within 30ms", d, err)
+		}
+	})
+	t.Run("zero tokens", func(t *testing.T) {
+		w := NewSlidingWindow(1, time.Second)
+		w.Reserve(context.Background(), 1)
+		if d, err := elapsed(w.Reserve(context.Background(), 0)); err != nil || d > 10*time.Millisecond {
+			t.Errorf("got %v, %v; want immediate resolve", d, err)
+		}
+	})
+	t.Run("negative tokens", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("want panic")
+			}
+		}()
+		NewSlidingWindow(1, time.Second).Reserve(context.Background(), -1)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("want panic")
+			}
+		}()
+		NewSlidingWindow(1, 0)
+	})
+}
@@ -0,0 +1,197 @@
+package azor
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff returns the delay before the next attempt,
+// given the number of failed attempts so far (starting at 1)
+// and the previous delay (zero before the first retry).
+type Backoff func(attempt int, prev time.Duration) time.Duration
+
+// ConstantBackoff returns a [Backoff] that always waits
+// for the same duration.
+func ConstantBackoff(d time.Duration) Backoff {
+	return func(int, time.Duration) time.Duration {
+		return d
+	}
+}
+
+// ExponentialBackoff returns a [Backoff] that doubles the delay
+// after each attempt, starting with base and capped at maxDelay.
+// If maxDelay is zero, the delay is not capped.
+func ExponentialBackoff(base, maxDelay time.Duration) Backoff {
+	return func(attempt int, _ time.Duration) time.Duration {
+		d := base
+		for range attempt - 1 {
+			if maxDelay > 0 && d >= maxDelay || d > d<<1 {
+				break
+			}
+			d <<= 1
+		}
+		return capDelay(d, maxDelay)
+	}
+}
+
+// DecorrelatedJitterBackoff returns a [Backoff] that picks a random
+// delay between base and three times the previous delay, capped at maxDelay.
+// If maxDelay is zero, the delay is not capped.
+//
+// Spreads retries from concurrent clients over time, so they don't
+// hit the failing dependency at the same moments. See the "Exponential
+// Backoff And Jitter" article from the AWS Architecture Blog for details.
+func DecorrelatedJitterBackoff(base, maxDelay time.Duration) Backoff {
+	return func(_ int, prev time.Duration) time.Duration {
+		hi := max(prev, base) * 3
+		d := base
+		if hi > base {
+			d += rand.N(hi - base)
+		}
+		return capDelay(d, maxDelay)
+	}
+}
+
+// capDelay limits the delay to maxDelay
+// (if maxDelay is positive).
+func capDelay(d, maxDelay time.Duration) time.Duration {
+	if maxDelay > 0 && d > maxDelay {
+		return maxDelay
+	}
+	return d
+}
+
+// RetryPolicy controls how [Retry] repeats failed calls.
+// The zero value retries immediately and without limit,
+// until the call succeeds or the context is canceled.
+type RetryPolicy struct {
+	// Backoff returns the delay before the next attempt.
+	// If nil, retries immediately.
+	Backoff Backoff
+
+	// MaxAttempts is the maximum number of attempts,
+	// including the first one. Zero means no limit.
+	MaxAttempts int
+
+	// MaxElapsed is the maximum time since the first attempt.
+	// Retry gives up instead of waiting for an attempt that would
+	// start after that. Zero means no limit.
+	MaxElapsed time.Duration
+
+	// Retryable reports whether the call that failed
+	// with the given error should be retried.
+	// If nil, all errors are retried.
+	Retryable func(error) bool
+}
+
+// Attempt describes a single call made by [Retry].
+type Attempt struct {
+	Start    time.Time     // when the call started
+	Duration time.Duration // how long the call took
+	Err      error         // the error returned by the call
+}
+
+// RetryError is the error [Retry] rejects with when it gives up.
+type RetryError struct {
+	// Attempts lists all the failed attempts in order.
+	Attempts []Attempt
+	// Err is the reason Retry gave up: either the last
+	// attempt's error, or the context's error if the context
+	// was canceled.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("retry failed after %d attempts: %v", len(e.Attempts), e.Err)
+}
+
+// Unwrap returns the reason Retry gave up.
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// Retry calls the given function asynchronously and repeats the call
+// according to the policy until it succeeds. Returns a [Promise] that
+// resolves with the result of the first successful call.
+//
+// If Retry gives up (the policy allows no more attempts, the error is not
+// retryable, or the context is canceled), the promise rejects with
+// a [*RetryError] that lists all the attempts. A panic in the function
+// counts as a failed attempt.
+//
+// The function receives the given context, and should stop
+// when the context is canceled. Retry also stops waiting between
+// attempts when the context is canceled.
+//
+// Panics if the function is nil.
+func Retry[T any](ctx context.Context, policy RetryPolicy, fn func(context.Context) (T, error)) *Promise[T] {
+	if fn == nil {
+		panic("azor: nil function")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return Run(func() (T, error) {
+		var zero T
+		var attempts []Attempt
+		var delay time.Duration
+		start := time.Now()
+		for {
+			if err := ctx.Err(); err != nil {
+				return zero, &RetryError{Attempts: attempts, Err: err}
+			}
+
+			at := time.Now()
+			val, err := try(func() (T, error) { return fn(ctx) })
+			if err == nil {
+				return val, nil
+			}
+			attempts = append(attempts, Attempt{Start: at, Duration: time.Since(at), Err: err})
+
+			// Decide whether to try again.
+			if !policy.retryable(len(attempts), err) {
+				return zero, &RetryError{Attempts: attempts, Err: err}
+			}
+			if policy.Backoff != nil {
+				delay = policy.Backoff(len(attempts), delay)
+			}
+			if policy.MaxElapsed > 0 && time.Since(start)+delay > policy.MaxElapsed {
+				return zero, &RetryError{Attempts: attempts, Err: err}
+			}
+
+			// Wait before the next attempt.
+			if err := sleep(ctx, delay); err != nil {
+				return zero, &RetryError{Attempts: attempts, Err: err}
+			}
+		}
+	})
+}
+
+// retryable reports whether the policy allows
+// another attempt after the given number of attempts
+// failed with the given error.
+func (p RetryPolicy) retryable(attempts int, err error) bool {
+	if p.MaxAttempts > 0 && attempts >= p.MaxAttempts {
+		return false
+	}
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// sleep pauses for the given duration
+// or until the context is canceled.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,214 @@
+package azor
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	t.Run("first attempt", func(t *testing.T) {
+		var calls atomic.Int32
+		p := Retry(t.Context(), RetryPolicy{MaxAttempts: 3}, func(ctx context.Context) (int, error) {
+			calls.Add(1)
+			return 42, nil
+		})
+
+		val, err := p.Get(t.Context())
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if val != 42 {
+			t.Errorf("got val = %d, want 42", val)
+		}
+		if calls.Load() != 1 {
+			t.Errorf("got %d calls, want 1", calls.Load())
+		}
+	})
+	t.Run("eventual success", func(t *testing.T) {
+		var calls atomic.Int32
+		p := Retry(t.Context(), RetryPolicy{MaxAttempts: 5}, func(ctx context.Context) (int, error) {
+			if calls.Add(1) < 3 {
+				return 0, errDummy
+			}
+			return 42, nil
+		})
+
+		val, err := p.Get(t.Context())
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if val != 42 {
+			t.Errorf("got val = %d, want 42", val)
+		}
+		if calls.Load() != 3 {
+			t.Errorf("got %d calls, want 3", calls.Load())
+		}
+	})
+	t.Run("max attempts", func(t *testing.T) {
+		p := Retry(t.Context(), RetryPolicy{MaxAttempts: 3}, func(ctx context.Context) (int, error) {
+			return 0, errDummy
+		})
+
+		_, err := p.Get(t.Context())
+		var rerr *RetryError
+		if !errors.As(err, &rerr) {
+			t.Fatalf("got err = %v, want *RetryError", err)
+		}
+		if len(rerr.Attempts) != 3 {
+			t.Errorf("got %d attempts, want 3", len(rerr.Attempts))
+		}
+		for i, at := range rerr.Attempts {
+			if !errors.Is(at.Err, errDummy) {
+				t.Errorf("attempt %d: got err = %v, want %v", i, at.Err, errDummy)
+			}
+		}
+		if !errors.Is(err, errDummy) {
+			t.Errorf("got err = %v, want %v", err, errDummy)
+		}
+		want := "retry failed after 3 attempts: dummy"
+		if err.Error() != want {
+			t.Errorf("got err = %q, want %q", err, want)
+		}
+	})
+	t.Run("not retryable", func(t *testing.T) {
+		errFatal := errors.New("fatal")
+		var calls atomic.Int32
+		policy := RetryPolicy{
+			MaxAttempts: 5,
+			Retryable:   func(err error) bool { return !errors.Is(err, errFatal) },
+		}
+		p := Retry(t.Context(), policy, func(ctx context.Context) (int, error) {
+			if calls.Add(1) == 2 {
+				return 0, errFatal
+			}
+			return 0, errDummy
+		})
+
+		_, err := p.Get(t.Context())
+		if !errors.Is(err, errFatal) {
+			t.Errorf("got err = %v, want %v", err, errFatal)
+		}
+		if calls.Load() != 2 {
+			t.Errorf("got %d calls, want 2", calls.Load())
+		}
+	})
+	t.Run("max elapsed", func(t *testing.T) {
+		var calls atomic.Int32
+		policy := RetryPolicy{
+			Backoff:    ConstantBackoff(20 * time.Millisecond),
+			MaxElapsed: 50 * time.Millisecond,
+		}
+		p := Retry(t.Context(), policy, func(ctx context.Context) (int, error) {
+			calls.Add(1)
+			return 0, errDummy
+		})
+
+		_, err := p.Get(t.Context())
+		if !errors.Is(err, errDummy) {
+			t.Errorf("got err = %v, want %v", err, errDummy)
+		}
+		if calls.Load() != 3 {
+			t.Errorf("got %d calls, want 3", calls.Load())
+		}
+	})
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		var calls atomic.Int32
+		policy := RetryPolicy{Backoff: ConstantBackoff(time.Second)}
+		p := Retry(ctx, policy, func(ctx context.Context) (int, error) {
+			calls.Add(1)
+			return 0, errDummy
+		})
+
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+
+		_, err := p.Get(t.Context())
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got err = %v, want %v", err, context.Canceled)
+		}
+		var rerr *RetryError
+		if errors.As(err, &rerr) && len(rerr.Attempts) != 1 {
+			t.Errorf("got %d attempts, want 1", len(rerr.Attempts))
+		}
+	})
+	t.Run("canceled before start", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		p := Retry(ctx, RetryPolicy{}, func(ctx context.Context) (int, error) {
+			t.Error("should not be called")
+			return 42, nil
+		})
+
+		_, err := p.Get(t.Context())
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got err = %v, want %v", err, context.Canceled)
+		}
+	})
+	t.Run("panic", func(t *testing.T) {
+		p := Retry(t.Context(), RetryPolicy{MaxAttempts: 3}, func(ctx context.Context) (int, error) {
+			panic("oops")
+		})
+
+		_, err := p.Get(t.Context())
+		var rerr *RetryError
+		if !errors.As(err, &rerr) {
+			t.Fatalf("got err = %v, want *RetryError", err)
+		}
+		if len(rerr.Attempts) != 3 {
+			t.Errorf("got %d attempts, want 3", len(rerr.Attempts))
+		}
+		want := "retry failed after 3 attempts: panic: oops"
+		if err.Error() != want {
+			t.Errorf("got err = %q, want %q", err, want)
+		}
+	})
+	t.Run("nil function", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("should panic for nil function")
+			}
+		}()
+		Retry[int](t.Context(), RetryPolicy{}, nil)
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	t.Run("constant", func(t *testing.T) {
+		b := ConstantBackoff(time.Second)
+		for attempt := 1; attempt <= 3; attempt++ {
+			if got := b(attempt, time.Second); got != time.Second {
+				t.Errorf("attempt %d: got %v, want 1s", attempt, got)
+			}
+		}
+	})
+	t.Run("exponential", func(t *testing.T) {
+		b := ExponentialBackoff(time.Second, 5*time.Second)
+		want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+		for i, w := range want {
+			if got := b(i+1, 0); got != w {
+				t.Errorf("attempt %d: got %v, want %v", i+1, got, w)
+			}
+		}
+	})
+	t.Run("exponential overflow", func(t *testing.T) {
+		b := ExponentialBackoff(time.Second, 0)
+		if got := b(1000, 0); got <= 0 {
+			t.Errorf("got %v, want positive", got)
+		}
+	})
+	t.Run("decorrelated jitter", func(t *testing.T) {
+		b := DecorrelatedJitterBackoff(time.Second, 10*time.Second)
+		var prev time.Duration
+		for attempt := 1; attempt <= 100; attempt++ {
+			d := b(attempt, prev)
+			if d < time.Second || d > 10*time.Second || d > max(prev, time.Second)*3 {
+				t.Fatalf("attempt %d: got %v, prev %v", attempt, d, prev)
+			}
+			prev = d
+		}
+	})
+}
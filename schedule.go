@@ -0,0 +1,292 @@
+package azor
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and runs functions after a delay.
+// [ScheduleConfig] accepts a custom clock to control
+// the time in tests.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// AfterFunc calls f in its own goroutine after the duration.
+	// Returns a function that stops the call, reporting whether
+	// it stopped the call before it started.
+	AfterFunc(d time.Duration, f func()) (stop func() bool)
+}
+
+// systemClock is a [Clock] that uses the system time.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) func() bool {
+	return time.AfterFunc(d, f).Stop
+}
+
+// OverlapPolicy controls what a [Job] does when it's time
+// for a run while the previous run is still in progress.
+type OverlapPolicy int
+
+const (
+	// OverlapAllow starts the run anyway,
+	// so several runs can be in progress at once.
+	OverlapAllow OverlapPolicy = iota
+	// OverlapSkip skips the run.
+	OverlapSkip
+)
+
+// ScheduleConfig configures [After], [Every] and [Cron].
+// The zero value uses the system clock, allows overlapping
+// runs, and uses the local time zone for cron expressions.
+type ScheduleConfig struct {
+	// Clock tells the time and runs the timers.
+	// If nil, uses the system clock.
+	Clock Clock
+
+	// Overlap controls what happens when it's time for a run
+	// while the previous run is still in progress.
+	Overlap OverlapPolicy
+
+	// Location is the time zone for cron expressions
+	// that don't specify one. If nil, uses time.Local.
+	Location *time.Location
+}
+
+// Job is a scheduled function that runs once or repeatedly.
+// Each run produces a [Promise]: use [Job.Next] to wait for the
+// upcoming run, or [Job.Results] to receive the results of all the
+// runs as a sequence. Call [Job.Stop] to cancel the future runs.
+//
+// Job is safe for concurrent use.
+type Job[T any] struct {
+	fn      func() (T, error)
+	next    func(after time.Time) time.Time // zero if there are no more runs
+	clock   Clock
+	overlap OverlapPolicy
+
+	mu      sync.Mutex
+	stop    func() bool // stops the timer
+	pending *jobRun[T]  // the upcoming run
+	last    *Promise[T] // the latest run, nil before the first one
+	running int         // number of runs in progress
+	stopped bool
+}
+
+// jobRun is a run of a job. Runs form a linked list,
+// so that sequences of results can follow it.
+type jobRun[T any] struct {
+	p       *Promise[T]
+	resolve func(T)
+	reject  func(error)
+	started chan struct{} // closed when the run starts or the job stops
+	stopped bool          // true if the job stopped before the run
+	next    *jobRun[T]    // the run after this one
+}
+
+// After returns a job that calls fn once, after the duration.
+//
+// Panics if the function is nil.
+func After[T any](d time.Duration, fn func() (T, error), cfg ScheduleConfig) *Job[T] {
+	var at time.Time
+	return newJob(fn, func(after time.Time) time.Time {
+		if !at.IsZero() {
+			return time.Time{}
+		}
+		at = after.Add(d)
+		return at
+	}, cfg)
+}
+
+// Every returns a job that calls fn repeatedly, once per interval,
+// starting one interval from now. The interval is measured between
+// the starts of the runs, not from the end of one run to the start
+// of the next one.
+//
+// Panics if the function is nil or the interval is not positive.
+func Every[T any](interval time.Duration, fn func() (T, error), cfg ScheduleConfig) *Job[T] {
+	if interval <= 0 {
+		panic("azor: non-positive interval")
+	}
+	return newJob(fn, func(after time.Time) time.Time {
+		return after.Add(interval)
+	}, cfg)
+}
+
+// Cron returns a job that calls fn at the times matching the cron
+// expression. See [ParseCron] for the expression syntax. Returns
+// an error if the expression is invalid.
+//
+// Panics if the function is nil.
+func Cron[T any](expr string, fn func() (T, error), cfg ScheduleConfig) (*Job[T], error) {
+	spec, err := ParseCron(expr, cfg.Location)
+	if err != nil {
+		return nil, err
+	}
+	return newJob(fn, spec.Next, cfg), nil
+}
+
+// newJob creates a new job and schedules its first run.
+func newJob[T any](fn func() (T, error), next func(time.Time) time.Time, cfg ScheduleConfig) *Job[T] {
+	if fn == nil {
+		panic("azor: nil function")
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = systemClock{}
+	}
+	j := &Job[T]{
+		fn:      fn,
+		next:    next,
+		clock:   cfg.Clock,
+		overlap: cfg.Overlap,
+		pending: newJobRun[T](),
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := j.clock.Now()
+	j.schedule(now, j.next(now))
+	return j
+}
+
+// Next returns a [Promise] for the upcoming run, which resolves
+// with the run's result. If the job stops before the run starts,
+// the promise rejects with [ErrClosed].
+func (j *Job[T]) Next() *Promise[T] {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.pending.p
+}
+
+// Last returns the [Promise] of the latest run,
+// or nil if the job hasn't run yet.
+func (j *Job[T]) Last() *Promise[T] {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.last
+}
+
+// Results returns a sequence of the results of the runs that start
+// after the call, in the order they start. Each value is available
+// when its run finishes. The sequence ends after the last run,
+// when the job is stopped or has no more runs.
+func (j *Job[T]) Results() *AsyncSeq[Result[T]] {
+	j.mu.Lock()
+	run := j.pending
+	j.mu.Unlock()
+
+	return FuncSeq(func(ctx context.Context) (Result[T], error) {
+		var res Result[T]
+		select {
+		case <-run.started:
+		case <-ctx.Done():
+			return res, ctx.Err()
+		}
+		if run.stopped {
+			return res, ErrEnd
+		}
+		val, err := run.p.Get(ctx)
+		if err != nil && ctx.Err() != nil {
+			return res, ctx.Err()
+		}
+		run = run.next
+		return Result[T]{val, err}, nil
+	}, nil)
+}
+
+// Stop cancels the future runs. The runs in progress continue,
+// and their promises settle as usual. Stop is safe to call
+// multiple times.
+func (j *Job[T]) Stop() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.stopLocked()
+}
+
+// stopLocked cancels the future runs.
+// Must be called with the mutex held.
+func (j *Job[T]) stopLocked() {
+	if j.stopped {
+		return
+	}
+	j.stopped = true
+	if j.stop != nil {
+		j.stop()
+	}
+	j.pending.stopped = true
+	j.pending.reject(ErrClosed)
+	close(j.pending.started)
+}
+
+// schedule sets the timer for the run at the given time,
+// or stops the job if the time is zero.
+// Must be called with the mutex held.
+func (j *Job[T]) schedule(now, at time.Time) {
+	if at.IsZero() {
+		j.stopLocked()
+		return
+	}
+	j.stop = j.clock.AfterFunc(at.Sub(now), func() {
+		j.fire(at)
+	})
+}
+
+// fire starts the run scheduled at the given time
+// (unless it overlaps and should be skipped),
+// and schedules the next one.
+func (j *Job[T]) fire(at time.Time) {
+	j.mu.Lock()
+	if j.stopped {
+		j.mu.Unlock()
+		return
+	}
+	var run *jobRun[T]
+	if j.overlap != OverlapSkip || j.running == 0 {
+		run = j.pending
+		j.pending = newJobRun[T]()
+		run.next = j.pending
+		j.last = run.p
+		j.running++
+		close(run.started)
+	}
+
+	// Skip the runs missed while the clock was ahead,
+	// instead of running them all at once.
+	now := j.clock.Now()
+	next := j.next(at)
+	if !next.IsZero() && !next.After(now) {
+		next = j.next(now)
+	}
+	if !next.IsZero() && !next.After(now) {
+		// A schedule that doesn't move past now would
+		// fire over and over, so treat it as finished.
+		next = time.Time{}
+	}
+	j.schedule(now, next)
+	j.mu.Unlock()
+
+	if run == nil {
+		return
+	}
+	go func() {
+		val, err := try(j.fn)
+		j.mu.Lock()
+		j.running--
+		j.mu.Unlock()
+		if err != nil {
+			run.reject(err)
+		} else {
+			run.resolve(val)
+		}
+	}()
+}
+
+// newJobRun creates a new pending run.
+func newJobRun[T any]() *jobRun[T] {
+	p, resolve, reject := WithResolvers[T]()
+	return &jobRun[T]{p: p, resolve: resolve, reject: reject, started: make(chan struct{})}
+}
@@ -0,0 +1,341 @@
+package azor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock is a [Clock] that only moves when advanced.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// fakeTimer is a pending call of a fake clock.
+type fakeTimer struct {
+	at   time.Time
+	f    func()
+	done bool // fired or stopped
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) func() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	return func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		stopped := !t.done
+		t.done = true
+		return stopped
+	}
+}
+
+// Advance moves the clock forward and calls
+// the functions that are due, earliest first.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+	for {
+		c.mu.Lock()
+		var next *fakeTimer
+		for _, t := range c.timers {
+			if !t.done && !t.at.After(c.now) && (next == nil || t.at.Before(next.at)) {
+				next = t
+			}
+		}
+		if next == nil {
+			c.mu.Unlock()
+			return
+		}
+		next.done = true
+		c.mu.Unlock()
+		next.f()
+	}
+}
+
+func TestAfter(t *testing.T) {
+	t.Run("run", func(t *testing.T) {
+		clock := newFakeClock(time.Now())
+		job := After(time.Minute, func() (int, error) { return 42, nil }, ScheduleConfig{Clock: clock})
+		p := job.Next()
+		clock.Advance(59 * time.Second)
+		if job.Last() != nil {
+			t.Error("want no run before the time")
+		}
+		clock.Advance(time.Second)
+		if val, err := p.Get(t.Context()); val != 42 || err != nil {
+			t.Errorf("got %d, %v; want 42, nil", val, err)
+		}
+		if job.Last() != p {
+			t.Error("want the last run's promise")
+		}
+		// No more runs.
+		if _, err := job.Next().Get(t.Context()); !errors.Is(err, ErrClosed) {
+			t.Errorf("got %v, want ErrClosed", err)
+		}
+	})
+	t.Run("stop", func(t *testing.T) {
+		clock := newFakeClock(time.Now())
+		var calls atomic.Int32
+		job := After(time.Minute, func() (int, error) { return int(calls.Add(1)), nil }, ScheduleConfig{Clock: clock})
+		job.Stop()
+		job.Stop()
+		clock.Advance(time.Hour)
+		if _, err := job.Next().Get(t.Context()); !errors.Is(err, ErrClosed) {
+			t.Errorf("got %v, want ErrClosed", err)
+		}
+		if n := calls.Load(); n != 0 {
+			t.Errorf("got %d calls, want 0", n)
+		}
+	})
+	t.Run("system clock", func(t *testing.T) {
+		start := time.Now()
+		job := After(10*time.Millisecond, func() (int, error) { return 42, nil }, ScheduleConfig{})
+		if val, err := job.Next().Get(t.Context()); val != 42 || err != nil {
+			t.Errorf("got %d, %v; want 42, nil", val, err)
+		}
+		if d := time.Since(start); d < 10*time.Millisecond {
+			t.Errorf("got %v, want at least 10ms", d)
+		}
+	})
+	t.Run("nil function", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("want panic")
+			}
+		}()
+		After[int](time.Second, nil, ScheduleConfig{})
+	})
+}
+
+func TestEvery(t *testing.T) {
+	t.Run("results", func(t *testing.T) {
+		clock := newFakeClock(time.Now())
+		var calls atomic.Int32
+		job := Every(time.Minute, func() (int, error) {
+			n := int(calls.Add(1))
+			if n == 2 {
+				return 0, errDummy
+			}
+			return n, nil
+		}, ScheduleConfig{Clock: clock})
+		results := job.Results()
+		for range 3 {
+			p := job.Next()
+			clock.Advance(time.Minute)
+			p.Get(t.Context())
+		}
+		job.Stop()
+
+		var got []Result[int]
+		for res, err := range results.All(t.Context()) {
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got = append(got, res)
+		}
+		if len(got) != 3 {
+			t.Fatalf("got %d results, want 3", len(got))
+		}
+		if got[0].Val != 1 || got[0].Err != nil {
+			t.Errorf("#0: got %v, want 1", got[0])
+		}
+		if !errors.Is(got[1].Err, errDummy) {
+			t.Errorf("#1: got %v, want %v", got[1].Err, errDummy)
+		}
+		if got[2].Val != 3 || got[2].Err != nil {
+			t.Errorf("#2: got %v, want 3", got[2])
+		}
+	})
+	t.Run("skip missed", func(t *testing.T) {
+		clock := newFakeClock(time.Now())
+		var calls atomic.Int32
+		job := Every(time.Minute, func() (int, error) { return int(calls.Add(1)), nil }, ScheduleConfig{Clock: clock})
+		defer job.Stop()
+		p := job.Next()
+		clock.Advance(5 * time.Minute)
+		p.Get(t.Context())
+		if n := calls.Load(); n != 1 {
+			t.Errorf("got %d calls, want 1", n)
+		}
+		// The next run is one interval after the clock's time.
+		p = job.Next()
+		clock.Advance(59 * time.Second)
+		if job.Last() == p {
+			t.Error("want no run before the interval")
+		}
+		clock.Advance(time.Second)
+		if val, _ := p.Get(t.Context()); val != 2 {
+			t.Errorf("got %d, want 2", val)
+		}
+	})
+	t.Run("overlap skip", func(t *testing.T) {
+		clock := newFakeClock(time.Now())
+		release := make(chan struct{})
+		var calls atomic.Int32
+		job := Every(time.Minute, func() (int, error) {
+			n := int(calls.Add(1))
+			if n == 1 {
+				<-release
+			}
+			return n, nil
+		}, ScheduleConfig{Clock: clock, Overlap: OverlapSkip})
+		defer job.Stop()
+
+		first := job.Next()
+		clock.Advance(time.Minute)
+		second := job.Next()
+		clock.Advance(time.Minute) // skipped, the first run is in progress
+		if second == job.Last() {
+			t.Error("want the second run skipped")
+		}
+		close(release)
+		first.Get(t.Context())
+		clock.Advance(time.Minute)
+		if val, _ := second.Get(t.Context()); val != 2 {
+			t.Errorf("got %d, want 2", val)
+		}
+	})
+	t.Run("overlap allow", func(t *testing.T) {
+		clock := newFakeClock(time.Now())
+		release := make(chan struct{})
+		var running atomic.Int32
+		job := Every(time.Minute, func() (int, error) {
+			n := running.Add(1)
+			<-release
+			return int(n), nil
+		}, ScheduleConfig{Clock: clock})
+		p1 := job.Next()
+		clock.Advance(time.Minute)
+		p2 := job.Next()
+		clock.Advance(time.Minute)
+		job.Stop()
+		close(release)
+		v1, _ := p1.Get(t.Context())
+		v2, _ := p2.Get(t.Context())
+		if max(v1, v2) != 2 {
+			t.Errorf("got %d and %d, want 2 runs at once", v1, v2)
+		}
+	})
+	t.Run("results canceled", func(t *testing.T) {
+		clock := newFakeClock(time.Now())
+		job := Every(time.Minute, func() (int, error) { return 1, nil }, ScheduleConfig{Clock: clock})
+		defer job.Stop()
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := job.Results().Next(ctx).Get(t.Context()); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got %v, want context.DeadlineExceeded", err)
+		}
+	})
+	t.Run("invalid interval", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("want panic")
+			}
+		}()
+		Every(0, func() (int, error) { return 0, nil }, ScheduleConfig{})
+	})
+}
+
+func TestCron(t *testing.T) {
+	t.Run("run", func(t *testing.T) {
+		start := time.Date(2026, 1, 1, 10, 7, 0, 0, time.UTC)
+		clock := newFakeClock(start)
+		var times []time.Time
+		job, err := Cron("*/15 * * * *", func() (time.Time, error) {
+			return clock.Now(), nil
+		}, ScheduleConfig{Clock: clock, Location: time.UTC})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, d := range []time.Duration{8 * time.Minute, 15 * time.Minute, 15 * time.Minute} {
+			p := job.Next()
+			clock.Advance(d)
+			val, _ := p.Get(t.Context())
+			times = append(times, val)
+		}
+		job.Stop()
+		want := []time.Time{
+			time.Date(2026, 1, 1, 10, 15, 0, 0, time.UTC),
+			time.Date(2026, 1, 1, 10, 30, 0, 0, time.UTC),
+			time.Date(2026, 1, 1, 10, 45, 0, 0, time.UTC),
+		}
+		for i := range want {
+			if !times[i].Equal(want[i]) {
+				t.Errorf("#%d: got %v, want %v", i, times[i], want[i])
+			}
+		}
+	})
+	t.Run("dst fall back", func(t *testing.T) {
+		newYork, err := time.LoadLocation("America/New_York")
+		if err != nil {
+			t.Skip("no time zone database")
+		}
+		// 01:00-01:59 repeats on 2024-11-03 in New York.
+		start := time.Date(2024, 11, 3, 5, 55, 0, 0, time.UTC) // 01:55 EDT
+		clock := newFakeClock(start)
+		var times []time.Time
+		job, _ := Cron("*/30 * * * *", func() (time.Time, error) {
+			return clock.Now(), nil
+		}, ScheduleConfig{Clock: clock, Location: newYork})
+		for _, d := range []time.Duration{5 * time.Minute, 30 * time.Minute, 30 * time.Minute} {
+			p := job.Next()
+			clock.Advance(d)
+			val, _ := p.Get(t.Context())
+			times = append(times, val)
+		}
+		job.Stop()
+		want := []time.Time{
+			time.Date(2024, 11, 3, 6, 0, 0, 0, time.UTC), // 01:00 EST
+			time.Date(2024, 11, 3, 6, 30, 0, 0, time.UTC),
+			time.Date(2024, 11, 3, 7, 0, 0, 0, time.UTC),
+		}
+		for i := range want {
+			if !times[i].Equal(want[i]) {
+				t.Errorf("#%d: got %v, want %v", i, times[i], want[i])
+			}
+		}
+	})
+	t.Run("stuck schedule", func(t *testing.T) {
+		start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+		clock := newFakeClock(start)
+		// The schedule never moves past the first run,
+		// so the job stops instead of firing over and over.
+		job := newJob(func() (int, error) {
+			return 1, nil
+		}, func(time.Time) time.Time {
+			return start.Add(time.Minute)
+		}, ScheduleConfig{Clock: clock})
+		clock.Advance(time.Minute)
+		if _, err := job.Next().Get(t.Context()); !errors.Is(err, ErrClosed) {
+			t.Errorf("got %v, want ErrClosed", err)
+		}
+		if _, err := job.Last().Get(t.Context()); err != nil {
+			t.Errorf("got %v, want the first run to succeed", err)
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := Cron("* * *", func() (int, error) { return 0, nil }, ScheduleConfig{})
+		if err == nil {
+			t.Error("want error")
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package azor
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Scope is a group of asynchronous calls (children) that belong
+// to the same operation, such as handling a request. Use [WithScope]
+// to create a scope and [Go] to start children in it.
+//
+// A scope does not finish until all its children finish, so no child
+// outlives the operation that started it (structured concurrency).
+type Scope struct {
+	ctx    context.Context // canceled on the first failure
+	cancel context.CancelCauseFunc
+
+	mu       sync.Mutex
+	idle     *sync.Cond // signaled when the last child finishes
+	running  int        // number of running children
+	errs     []error    // children's errors in the order they failed
+	failFast bool       // cancel the scope on the first failure
+	failed   bool       // true if the scope was canceled because of a failure
+	failedAt int        // number of children's errors when the scope failed
+	closed   bool       // true when the scope is finished
+}
+
+// WithScope creates a new scope and calls fn with it. Waits for fn and
+// all the children started with [Go] to finish, and returns the combined
+// error of fn and the children (joined with [errors.Join]), or nil if
+// all of them succeed.
+//
+// By default, the scope fails fast: when fn or any child fails,
+// the scope's context is canceled, which signals the other children
+// to stop. Use [Scope.SetFailFast] to let the children run to completion
+// regardless of failures. The scope's context is also canceled when
+// the parent context is canceled, and when WithScope returns.
+//
+// Children that fail with the context's error after the scope is
+// canceled because of another failure are not included in the combined
+// error, since they only report the cancellation.
+//
+// Panics if the function is nil.
+func WithScope(ctx context.Context, fn func(s *Scope) error) error {
+	if fn == nil {
+		panic("azor: nil function")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	s := &Scope{ctx: ctx, cancel: cancel, failFast: true}
+	s.idle = sync.NewCond(&s.mu)
+
+	_, err := try(func() (struct{}, error) { return struct{}{}, fn(s) })
+	if err != nil {
+		s.fail(err)
+	}
+	return errors.Join(err, s.wait())
+}
+
+// Context returns the scope's context. It is canceled on the first
+// failure (if the scope fails fast), when the parent context is
+// canceled, or when the scope is finished.
+func (s *Scope) Context() context.Context {
+	return s.ctx
+}
+
+// SetFailFast sets whether the scope cancels its context on the first
+// failure. The default is true. If false, the children run to completion
+// (unless the parent context is canceled), and the scope still reports
+// all their errors.
+func (s *Scope) SetFailFast(failFast bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failFast = failFast
+}
+
+// Go calls the given function asynchronously as a child of the scope
+// and returns a [Promise] that resolves with the function's result.
+// The function receives the scope's context, and should stop when
+// the context is canceled.
+//
+// Children can start other children in the same scope.
+// After the scope is finished, Go does not call the function
+// and returns a promise rejected with [ErrClosed].
+//
+// Panics if the function is nil.
+func Go[T any](s *Scope, fn func(context.Context) (T, error)) *Promise[T] {
+	if fn == nil {
+		panic("azor: nil function")
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return rejected[T](ErrClosed)
+	}
+	s.running++
+	s.mu.Unlock()
+
+	return Run(func() (T, error) {
+		val, err := try(func() (T, error) { return fn(s.ctx) })
+		s.done(err)
+		return val, err
+	})
+}
+
+// done records the result of a finished child.
+func (s *Scope) done(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.errs = append(s.errs, err)
+		if s.failFast {
+			s.failLocked(err)
+		}
+	}
+	s.running--
+	if s.running == 0 {
+		s.idle.Broadcast()
+	}
+}
+
+// fail cancels the scope because of the given error,
+// if the scope fails fast.
+func (s *Scope) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failFast {
+		s.failLocked(err)
+	}
+}
+
+// failLocked cancels the scope because of the given error,
+// unless it is already canceled. Must be called with the mutex held.
+func (s *Scope) failLocked(err error) {
+	if s.ctx.Err() != nil {
+		return
+	}
+	s.failed = true
+	s.failedAt = len(s.errs)
+	s.cancel(err)
+}
+
+// wait waits for all the children to finish, closes the scope
+// and returns the children's combined error.
+func (s *Scope) wait() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.running > 0 {
+		s.idle.Wait()
+	}
+	s.closed = true
+
+	// If the scope was canceled because of a failure, drop the errors
+	// of the children that finished after it and only report the
+	// cancellation. The errors up to the failure are always kept,
+	// even if they wrap context.Canceled themselves.
+	if !s.failed {
+		return errors.Join(s.errs...)
+	}
+	errs := s.errs[:s.failedAt:s.failedAt]
+	for _, err := range s.errs[s.failedAt:] {
+		if !errors.Is(err, s.ctx.Err()) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
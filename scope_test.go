@@ -0,0 +1,233 @@
+package azor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithScope(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var p1, p2 *Promise[int]
+		err := WithScope(t.Context(), func(s *Scope) error {
+			p1 = Go(s, func(ctx context.Context) (int, error) {
+				time.Sleep(10 * time.Millisecond)
+				return 1, nil
+			})
+			p2 = Go(s, func(ctx context.Context) (int, error) {
+				return 2, nil
+			})
+			return nil
+		})
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		for i, p := range []*Promise[int]{p1, p2} {
+			select {
+			case <-p.Done():
+			default:
+				t.Fatalf("child %d not settled", i+1)
+			}
+			if val, _ := p.Get(t.Context()); val != i+1 {
+				t.Errorf("got %v, want %v", val, i+1)
+			}
+		}
+	})
+	t.Run("waits for children", func(t *testing.T) {
+		var finished atomic.Bool
+		_ = WithScope(t.Context(), func(s *Scope) error {
+			Go(s, func(ctx context.Context) (int, error) {
+				time.Sleep(20 * time.Millisecond)
+				finished.Store(true)
+				return 0, nil
+			})
+			return nil
+		})
+		if !finished.Load() {
+			t.Error("scope returned before child finished")
+		}
+	})
+	t.Run("nested children", func(t *testing.T) {
+		var finished atomic.Bool
+		_ = WithScope(t.Context(), func(s *Scope) error {
+			Go(s, func(ctx context.Context) (int, error) {
+				time.Sleep(10 * time.Millisecond)
+				Go(s, func(ctx context.Context) (int, error) {
+					time.Sleep(10 * time.Millisecond)
+					finished.Store(true)
+					return 0, nil
+				})
+				return 0, nil
+			})
+			return nil
+		})
+		if !finished.Load() {
+			t.Error("scope returned before nested child finished")
+		}
+	})
+	t.Run("fail fast", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		var canceled atomic.Bool
+		err := WithScope(t.Context(), func(s *Scope) error {
+			Go(s, func(ctx context.Context) (int, error) {
+				return 0, errFailed
+			})
+			Go(s, func(ctx context.Context) (int, error) {
+				select {
+				case <-ctx.Done():
+					canceled.Store(true)
+					return 0, ctx.Err()
+				case <-time.After(time.Second):
+					return 0, nil
+				}
+			})
+			return nil
+		})
+		if !errors.Is(err, errFailed) {
+			t.Errorf("got err = %v, want %v", err, errFailed)
+		}
+		if errors.Is(err, context.Canceled) {
+			t.Errorf("got err = %v, want no cancellation errors", err)
+		}
+		if !canceled.Load() {
+			t.Error("other child not canceled")
+		}
+	})
+	t.Run("fail fast canceled", func(t *testing.T) {
+		// The child fails with its own context.Canceled,
+		// which is not a report of the scope's cancellation.
+		errInner := fmt.Errorf("inner: %w", context.Canceled)
+		err := WithScope(t.Context(), func(s *Scope) error {
+			Go(s, func(ctx context.Context) (int, error) {
+				return 0, errInner
+			})
+			Go(s, func(ctx context.Context) (int, error) {
+				<-ctx.Done()
+				return 0, ctx.Err()
+			})
+			return nil
+		})
+		if !errors.Is(err, errInner) {
+			t.Errorf("got err = %v, want %v", err, errInner)
+		}
+		if err == nil || err.Error() != errInner.Error() {
+			t.Errorf("got err = %v, want only %v", err, errInner)
+		}
+	})
+	t.Run("no fail fast", func(t *testing.T) {
+		err1, err2 := errors.New("err1"), errors.New("err2")
+		var finished atomic.Bool
+		err := WithScope(t.Context(), func(s *Scope) error {
+			s.SetFailFast(false)
+			Go(s, func(ctx context.Context) (int, error) {
+				return 0, err1
+			})
+			Go(s, func(ctx context.Context) (int, error) {
+				select {
+				case <-ctx.Done():
+					return 0, ctx.Err()
+				case <-time.After(20 * time.Millisecond):
+					finished.Store(true)
+					return 0, err2
+				}
+			})
+			return nil
+		})
+		if !errors.Is(err, err1) || !errors.Is(err, err2) {
+			t.Errorf("got err = %v, want both errors", err)
+		}
+		if !finished.Load() {
+			t.Error("other child canceled")
+		}
+	})
+	t.Run("no fail fast body error", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		var finished atomic.Bool
+		err := WithScope(t.Context(), func(s *Scope) error {
+			s.SetFailFast(false)
+			Go(s, func(ctx context.Context) (int, error) {
+				select {
+				case <-ctx.Done():
+					return 0, ctx.Err()
+				case <-time.After(20 * time.Millisecond):
+					finished.Store(true)
+					return 0, nil
+				}
+			})
+			return errFailed
+		})
+		if !errors.Is(err, errFailed) {
+			t.Errorf("got err = %v, want %v", err, errFailed)
+		}
+		if !finished.Load() {
+			t.Error("child canceled")
+		}
+	})
+	t.Run("body error", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		var canceled atomic.Bool
+		err := WithScope(t.Context(), func(s *Scope) error {
+			Go(s, func(ctx context.Context) (int, error) {
+				<-ctx.Done()
+				canceled.Store(true)
+				return 0, ctx.Err()
+			})
+			return errFailed
+		})
+		if !errors.Is(err, errFailed) {
+			t.Errorf("got err = %v, want %v", err, errFailed)
+		}
+		if !canceled.Load() {
+			t.Error("child not canceled")
+		}
+	})
+	t.Run("panic", func(t *testing.T) {
+		err := WithScope(t.Context(), func(s *Scope) error {
+			Go(s, func(ctx context.Context) (int, error) {
+				panic("oops")
+			})
+			return nil
+		})
+		if err == nil || err.Error() != "panic: oops" {
+			t.Errorf("got err = %v, want panic: oops", err)
+		}
+	})
+	t.Run("parent canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		err := WithScope(ctx, func(s *Scope) error {
+			Go(s, func(ctx context.Context) (int, error) {
+				<-ctx.Done()
+				return 0, ctx.Err()
+			})
+			cancel()
+			return nil
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got err = %v, want %v", err, context.Canceled)
+		}
+	})
+	t.Run("go after close", func(t *testing.T) {
+		var scope *Scope
+		_ = WithScope(t.Context(), func(s *Scope) error {
+			scope = s
+			return nil
+		})
+		if scope.Context().Err() == nil {
+			t.Error("scope context not canceled")
+		}
+		called := false
+		p := Go(scope, func(ctx context.Context) (int, error) {
+			called = true
+			return 0, nil
+		})
+		if _, err := p.Get(t.Context()); !errors.Is(err, ErrClosed) {
+			t.Errorf("got err = %v, want %v", err, ErrClosed)
+		}
+		if called {
+			t.Error("function called after close")
+		}
+	})
+}
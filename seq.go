@@ -0,0 +1,230 @@
+package azor
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"sync"
+)
+
+// ErrEnd is the error that [AsyncSeq.Next] rejects with
+// when there are no more values in the sequence.
+var ErrEnd = errors.New("end of sequence")
+
+// AsyncSeq is an asynchronous sequence of values that arrive over time.
+// Use [AsyncSeq.Next] to pull the values one by one, or [AsyncSeq.All]
+// to range over them with a for loop, similar to "for await"
+// in JavaScript:
+//
+//	for val, err := range seq.All(ctx) {
+//	    if err != nil {
+//	        return err
+//	    }
+//	    fmt.Println(val)
+//	}
+//
+// A sequence is closed when it runs out of values, or when
+// [AsyncSeq.Close] is called. Closing a sequence releases
+// its resources (such as goroutines producing the values).
+// Always close sequences you don't read to the end.
+//
+// Pulls are served one at a time, so AsyncSeq is safe for
+// concurrent use. A zero AsyncSeq value is unusable.
+// Use [SliceSeq], [ChanSeq], [FuncSeq] or [IterSeq]
+// to create a new sequence.
+type AsyncSeq[T any] struct {
+	next func(context.Context) (T, error)
+	stop func()
+
+	ctx    context.Context // canceled when the sequence is closed
+	cancel context.CancelFunc
+	mu     sync.Mutex // serializes pulls
+	done   bool       // true when the sequence is closed
+	held   *T         // value pulled for a canceled caller, returned by the next pull
+}
+
+// newSeq creates a new sequence that pulls values with the next function
+// and calls the stop function (if not nil) when closed.
+func newSeq[T any](next func(context.Context) (T, error), stop func()) *AsyncSeq[T] {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &AsyncSeq[T]{next: next, stop: stop, ctx: ctx, cancel: cancel}
+}
+
+// SliceSeq returns a sequence of the slice elements.
+// The sequence does not copy the slice, so don't modify
+// the slice while reading from the sequence.
+func SliceSeq[T any](s []T) *AsyncSeq[T] {
+	i := 0
+	return newSeq(func(ctx context.Context) (T, error) {
+		if i >= len(s) {
+			var zero T
+			return zero, ErrEnd
+		}
+		i++
+		return s[i-1], nil
+	}, nil)
+}
+
+// ChanSeq returns a sequence of the values received from the channel.
+// The sequence ends when the channel is closed.
+// Closing the sequence does not close the channel.
+func ChanSeq[T any](ch <-chan T) *AsyncSeq[T] {
+	return newSeq(func(ctx context.Context) (T, error) {
+		select {
+		case val, ok := <-ch:
+			if !ok {
+				return val, ErrEnd
+			}
+			return val, nil
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}, nil)
+}
+
+// FuncSeq returns a sequence of the values produced by the next function.
+// Next should return [ErrEnd] when there are no more values, and should
+// stop when the context is canceled. Next is never called concurrently.
+//
+// The stop function (if not nil) is called once when the sequence
+// is closed. Use it to release the resources used by next.
+//
+// Panics if next is nil.
+func FuncSeq[T any](next func(context.Context) (T, error), stop func()) *AsyncSeq[T] {
+	if next == nil {
+		panic("azor: nil function")
+	}
+	return newSeq(next, stop)
+}
+
+// IterSeq returns a sequence of the values produced by the iterator.
+// The iterator runs until the sequence is closed or the iterator
+// finishes. Since the iterator is synchronous, it cannot be
+// interrupted by the context while producing a value.
+//
+// Panics if the iterator is nil.
+func IterSeq[T any](seq iter.Seq[T]) *AsyncSeq[T] {
+	if seq == nil {
+		panic("azor: nil function")
+	}
+	next, stop := iter.Pull(seq)
+	return newSeq(func(ctx context.Context) (T, error) {
+		if err := ctx.Err(); err != nil {
+			var zero T
+			return zero, err
+		}
+		val, ok := next()
+		if !ok {
+			return val, ErrEnd
+		}
+		return val, nil
+	}, stop)
+}
+
+// Next pulls the next value from the sequence asynchronously and returns
+// a [Promise] that resolves with the value. If there are no more values,
+// or the sequence is closed, the promise rejects with [ErrEnd].
+//
+// The context cancels the pull (the promise rejects with the context's
+// error), but does not close the sequence. If the value arrives after
+// the context is canceled, it is not lost: the next pull returns it.
+func (s *AsyncSeq[T]) Next(ctx context.Context) *Promise[T] {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return Run(func() (T, error) {
+		return s.pull(ctx)
+	})
+}
+
+// All returns an iterator over the values in the sequence,
+// suitable for ranging with a for loop.
+//
+// If pulling a value fails, the iterator yields the error and stops.
+// All closes the sequence when the loop is over, whether it ran out of
+// values, failed, or the caller broke out of it.
+func (s *AsyncSeq[T]) All(ctx context.Context) iter.Seq2[T, error] {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return func(yield func(T, error) bool) {
+		defer s.Close()
+		for {
+			val, err := s.pull(ctx)
+			if errors.Is(err, ErrEnd) {
+				return
+			}
+			if !yield(val, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Close closes the sequence and releases its resources.
+// Cancels the pull in progress (if any) and waits for it to return.
+// After Close, [AsyncSeq.Next] rejects with [ErrEnd].
+//
+// Close is safe to call multiple times.
+func (s *AsyncSeq[T]) Close() {
+	s.cancel()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.finish()
+}
+
+// pull pulls the next value from the sequence.
+func (s *AsyncSeq[T]) pull(ctx context.Context) (T, error) {
+	var zero T
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done {
+		return zero, ErrEnd
+	}
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+	if s.held != nil {
+		val := *s.held
+		s.held = nil
+		return val, nil
+	}
+
+	// Cancel the pull if the sequence is closed.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(s.ctx, cancel)
+	defer stop()
+
+	val, err := try(func() (T, error) { return s.next(ctx) })
+	if s.ctx.Err() != nil {
+		// Closed while pulling.
+		return zero, ErrEnd
+	}
+	if errors.Is(err, ErrEnd) {
+		s.finish()
+		return zero, ErrEnd
+	}
+	if err == nil && ctx.Err() != nil {
+		// The caller has stopped waiting,
+		// so keep the value for the next pull.
+		s.held = &val
+		return zero, ctx.Err()
+	}
+	return val, err
+}
+
+// finish marks the sequence as closed
+// and calls the stop function.
+// Must be called with the mutex held.
+func (s *AsyncSeq[T]) finish() {
+	if s.done {
+		return
+	}
+	s.done = true
+	s.cancel()
+	if s.stop != nil {
+		s.stop()
+	}
+}
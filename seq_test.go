@@ -0,0 +1,298 @@
+package azor
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// collect reads all the values from the sequence.
+func collect[T any](t *testing.T, s *AsyncSeq[T]) ([]T, error) {
+	t.Helper()
+	var vals []T
+	for val, err := range s.All(t.Context()) {
+		if err != nil {
+			return vals, err
+		}
+		vals = append(vals, val)
+	}
+	return vals, nil
+}
+
+func TestSliceSeq(t *testing.T) {
+	t.Run("values", func(t *testing.T) {
+		got, err := collect(t, SliceSeq([]int{1, 2, 3}))
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if !slices.Equal(got, []int{1, 2, 3}) {
+			t.Errorf("got %v, want [1 2 3]", got)
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		got, err := collect(t, SliceSeq([]int{}))
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("got %v, want empty", got)
+		}
+	})
+}
+
+func TestChanSeq(t *testing.T) {
+	t.Run("values", func(t *testing.T) {
+		ch := make(chan int)
+		go func() {
+			for i := range 3 {
+				ch <- i
+			}
+			close(ch)
+		}()
+
+		got, err := collect(t, ChanSeq(ch))
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if !slices.Equal(got, []int{0, 1, 2}) {
+			t.Errorf("got %v, want [0 1 2]", got)
+		}
+	})
+	t.Run("canceled", func(t *testing.T) {
+		ch := make(chan int)
+		s := ChanSeq(ch)
+		defer s.Close()
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+		_, err := s.Next(ctx).Get(t.Context())
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got err = %v, want %v", err, context.DeadlineExceeded)
+		}
+	})
+	t.Run("close while pulling", func(t *testing.T) {
+		ch := make(chan int)
+		s := ChanSeq(ch)
+		p := s.Next(t.Context())
+
+		time.Sleep(10 * time.Millisecond)
+		s.Close()
+
+		_, err := p.Get(t.Context())
+		if !errors.Is(err, ErrEnd) {
+			t.Errorf("got err = %v, want %v", err, ErrEnd)
+		}
+	})
+}
+
+func TestFuncSeq(t *testing.T) {
+	t.Run("values", func(t *testing.T) {
+		n := 0
+		var stopped atomic.Bool
+		s := FuncSeq(func(ctx context.Context) (int, error) {
+			if n == 3 {
+				return 0, ErrEnd
+			}
+			n++
+			return n, nil
+		}, func() { stopped.Store(true) })
+
+		got, err := collect(t, s)
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if !slices.Equal(got, []int{1, 2, 3}) {
+			t.Errorf("got %v, want [1 2 3]", got)
+		}
+		if !stopped.Load() {
+			t.Error("stop should be called")
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		n := 0
+		s := FuncSeq(func(ctx context.Context) (int, error) {
+			n++
+			if n == 2 {
+				return 0, errDummy
+			}
+			return n, nil
+		}, nil)
+
+		got, err := collect(t, s)
+		if !errors.Is(err, errDummy) {
+			t.Errorf("got err = %v, want %v", err, errDummy)
+		}
+		if !slices.Equal(got, []int{1}) {
+			t.Errorf("got %v, want [1]", got)
+		}
+	})
+	t.Run("panic", func(t *testing.T) {
+		s := FuncSeq(func(ctx context.Context) (int, error) {
+			panic("oops")
+		}, nil)
+
+		_, err := s.Next(t.Context()).Get(t.Context())
+		want := "panic: oops"
+		if err == nil || err.Error() != want {
+			t.Errorf("got err = %q, want %q", err, want)
+		}
+	})
+	t.Run("nil function", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("should panic for nil function")
+			}
+		}()
+		FuncSeq[int](nil, nil)
+	})
+}
+
+func TestIterSeq(t *testing.T) {
+	t.Run("values", func(t *testing.T) {
+		got, err := collect(t, IterSeq(slices.Values([]int{1, 2, 3})))
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if !slices.Equal(got, []int{1, 2, 3}) {
+			t.Errorf("got %v, want [1 2 3]", got)
+		}
+	})
+	t.Run("close", func(t *testing.T) {
+		var finished atomic.Bool
+		s := IterSeq(func(yield func(int) bool) {
+			defer finished.Store(true)
+			for i := 0; ; i++ {
+				if !yield(i) {
+					return
+				}
+			}
+		})
+
+		val, err := s.Next(t.Context()).Get(t.Context())
+		if err != nil || val != 0 {
+			t.Errorf("got %v, %v, want 0, nil", val, err)
+		}
+		s.Close()
+		if !finished.Load() {
+			t.Error("iterator should be stopped")
+		}
+	})
+}
+
+func TestAsyncSeq(t *testing.T) {
+	t.Run("next", func(t *testing.T) {
+		s := SliceSeq([]int{1, 2})
+		for _, want := range []int{1, 2} {
+			val, err := s.Next(t.Context()).Get(t.Context())
+			if err != nil {
+				t.Fatalf("got err = %v, want nil", err)
+			}
+			if val != want {
+				t.Errorf("got val = %d, want %d", val, want)
+			}
+		}
+		_, err := s.Next(t.Context()).Get(t.Context())
+		if !errors.Is(err, ErrEnd) {
+			t.Errorf("got err = %v, want %v", err, ErrEnd)
+		}
+	})
+	t.Run("concurrent next", func(t *testing.T) {
+		s := SliceSeq([]int{1, 2, 3})
+		p1 := s.Next(t.Context())
+		p2 := s.Next(t.Context())
+		p3 := s.Next(t.Context())
+
+		var got []int
+		for _, p := range []*Promise[int]{p1, p2, p3} {
+			val, err := p.Get(t.Context())
+			if err != nil {
+				t.Fatalf("got err = %v, want nil", err)
+			}
+			got = append(got, val)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, []int{1, 2, 3}) {
+			t.Errorf("got %v, want [1 2 3]", got)
+		}
+	})
+	t.Run("close", func(t *testing.T) {
+		var stops atomic.Int32
+		s := FuncSeq(func(ctx context.Context) (int, error) {
+			return 42, nil
+		}, func() { stops.Add(1) })
+
+		s.Close()
+		s.Close()
+		_, err := s.Next(t.Context()).Get(t.Context())
+		if !errors.Is(err, ErrEnd) {
+			t.Errorf("got err = %v, want %v", err, ErrEnd)
+		}
+		if stops.Load() != 1 {
+			t.Errorf("got %d stops, want 1", stops.Load())
+		}
+	})
+	t.Run("break", func(t *testing.T) {
+		var stopped atomic.Bool
+		s := FuncSeq(func(ctx context.Context) (int, error) {
+			return 42, nil
+		}, func() { stopped.Store(true) })
+
+		for val, err := range s.All(t.Context()) {
+			if err != nil || val != 42 {
+				t.Errorf("got %v, %v, want 42, nil", val, err)
+			}
+			break
+		}
+		if !stopped.Load() {
+			t.Error("sequence should be closed after break")
+		}
+	})
+	t.Run("next canceled", func(t *testing.T) {
+		s := SliceSeq([]int{1, 2, 3})
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		if _, err := s.Next(ctx).Get(t.Context()); !errors.Is(err, context.Canceled) {
+			t.Errorf("got err = %v, want %v", err, context.Canceled)
+		}
+		got, _ := collect(t, s)
+		if !slices.Equal(got, []int{1, 2, 3}) {
+			t.Errorf("got %v, want [1 2 3]", got)
+		}
+	})
+	t.Run("canceled while pulling", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		i := 0
+		s := FuncSeq(func(context.Context) (int, error) {
+			// Ignores the context and cancels it mid-pull.
+			cancel()
+			i++
+			if i > 2 {
+				return 0, ErrEnd
+			}
+			return i, nil
+		}, nil)
+		if _, err := s.Next(ctx).Get(t.Context()); !errors.Is(err, context.Canceled) {
+			t.Errorf("got err = %v, want %v", err, context.Canceled)
+		}
+		got, _ := collect(t, s)
+		if !slices.Equal(got, []int{1, 2}) {
+			t.Errorf("got %v, want [1 2]", got)
+		}
+	})
+	t.Run("all canceled", func(t *testing.T) {
+		s := ChanSeq(make(chan int))
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		var errs []error
+		for _, err := range s.All(ctx) {
+			errs = append(errs, err)
+		}
+		if len(errs) != 1 || !errors.Is(errs[0], context.Canceled) {
+			t.Errorf("got errs = %v, want [%v]", errs, context.Canceled)
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package stream
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nalgeon/azor"
+)
+
+// Merge returns a sequence of the values from all the source sequences,
+// in the order they arrive. Reads the sources concurrently.
+// The sequence ends when all the sources end. If a source fails,
+// the consumer receives the error, and the other sources keep going.
+func Merge[T any](srcs ...*azor.AsyncSeq[T]) *azor.AsyncSeq[T] {
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan azor.Result[T])
+	var once sync.Once
+
+	start := func() {
+		var wg sync.WaitGroup
+		for _, src := range srcs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					val, err := next(ctx, src)
+					if isEnd(err) || ctx.Err() != nil {
+						return
+					}
+					select {
+					case out <- azor.Result[T]{Val: val, Err: err}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+		}
+		go func() {
+			wg.Wait()
+			close(out)
+		}()
+	}
+
+	return azor.FuncSeq(func(pctx context.Context) (T, error) {
+		once.Do(start)
+		select {
+		case res, ok := <-out:
+			if !ok {
+				return res.Val, azor.ErrEnd
+			}
+			return res.Val, res.Err
+		case <-pctx.Done():
+			var zero T
+			return zero, pctx.Err()
+		}
+	}, func() {
+		cancel()
+		closeAll(srcs)
+	})
+}
+
+// Zip returns a sequence of pairs of values from the two source
+// sequences, taking one value from each. The sequence ends
+// when either source ends, and closes both sources.
+func Zip[A, B any](a *azor.AsyncSeq[A], b *azor.AsyncSeq[B]) *azor.AsyncSeq[Pair[A, B]] {
+	// A value from one source is kept until the other source
+	// has a value too, so a failed pull doesn't lose it.
+	var heldA *A
+	var heldB *B
+	return azor.FuncSeq(func(ctx context.Context) (Pair[A, B], error) {
+		var pair Pair[A, B]
+		var pa *azor.Promise[A]
+		var pb *azor.Promise[B]
+		if heldA == nil {
+			pa = a.Next(ctx)
+		}
+		if heldB == nil {
+			pb = b.Next(ctx)
+		}
+		var errA, errB error
+		if pa != nil {
+			var va A
+			if va, errA = pa.Get(context.Background()); errA == nil {
+				heldA = &va
+			}
+		}
+		if pb != nil {
+			var vb B
+			if vb, errB = pb.Get(context.Background()); errB == nil {
+				heldB = &vb
+			}
+		}
+		if err := errA; err != nil || errB != nil {
+			if err == nil {
+				err = errB
+			}
+			return pair, err
+		}
+		pair.First, pair.Second = *heldA, *heldB
+		heldA, heldB = nil, nil
+		return pair, nil
+	}, func() {
+		a.Close()
+		b.Close()
+	})
+}
+
+// Concat returns a sequence of the values from all the source
+// sequences, one sequence after another. Reads each source
+// to the end before moving on to the next one.
+func Concat[T any](srcs ...*azor.AsyncSeq[T]) *azor.AsyncSeq[T] {
+	i := 0
+	return azor.FuncSeq(func(ctx context.Context) (T, error) {
+		for i < len(srcs) {
+			val, err := next(ctx, srcs[i])
+			if isEnd(err) {
+				i++
+				continue
+			}
+			return val, err
+		}
+		var zero T
+		return zero, azor.ErrEnd
+	}, func() {
+		closeAll(srcs)
+	})
+}
@@ -0,0 +1,125 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nalgeon/azor"
+)
+
+func TestMerge(t *testing.T) {
+	t.Run("values", func(t *testing.T) {
+		a := newSource(0, 1, 2, 3)
+		b := newSource(0, 10, 20)
+		got, err := collect(t, Merge(a.AsyncSeq, b.AsyncSeq))
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, []int{1, 2, 3, 10, 20}) {
+			t.Errorf("got %v, want [1 2 3 10 20]", got)
+		}
+	})
+	t.Run("arrival order", func(t *testing.T) {
+		slow := newSource(50*time.Millisecond, 1)
+		fast := newSource(time.Millisecond, 2)
+		got, _ := collect(t, Merge(slow.AsyncSeq, fast.AsyncSeq))
+		if !slices.Equal(got, []int{2, 1}) {
+			t.Errorf("got %v, want [2 1]", got)
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		_, err := collect(t, Merge(azor.SliceSeq([]int{1}), failing[int](errFailed)))
+		if !errors.Is(err, errFailed) {
+			t.Errorf("got err = %v, want %v", err, errFailed)
+		}
+	})
+	t.Run("none", func(t *testing.T) {
+		got, err := collect(t, Merge[int]())
+		if err != nil || len(got) != 0 {
+			t.Errorf("got %v, %v; want empty, nil", got, err)
+		}
+	})
+	t.Run("close early", func(t *testing.T) {
+		a := newSource(0, 1, 2, 3)
+		b := newSource(time.Second, 10)
+		s := Merge(a.AsyncSeq, b.AsyncSeq)
+		if _, err := s.Next(t.Context()).Get(t.Context()); err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		s.Close()
+		if !a.closed.Load() || !b.closed.Load() {
+			t.Error("sources not closed")
+		}
+	})
+}
+
+func TestZip(t *testing.T) {
+	t.Run("values", func(t *testing.T) {
+		a := newSource(0, 1, 2, 3)
+		b := newSource(0, "one", "two")
+		got, err := collect(t, Zip(a.AsyncSeq, b.AsyncSeq))
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		want := []Pair[int, string]{{1, "one"}, {2, "two"}}
+		if !slices.Equal(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+		if !a.closed.Load() || !b.closed.Load() {
+			t.Error("sources not closed")
+		}
+	})
+	t.Run("canceled pull", func(t *testing.T) {
+		// The first source has a value right away,
+		// the second one is still waiting when the pull is canceled.
+		a := newSource(0, 1, 2)
+		b := newSource(30*time.Millisecond, "one", "two")
+		s := Zip(a.AsyncSeq, b.AsyncSeq)
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+		_, err := s.Next(ctx).Get(t.Context())
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got err = %v, want %v", err, context.DeadlineExceeded)
+		}
+
+		// The value from the first source is not lost.
+		got, err := collect(t, s)
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		want := []Pair[int, string]{{1, "one"}, {2, "two"}}
+		if !slices.Equal(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+}
+
+func TestConcat(t *testing.T) {
+	t.Run("values", func(t *testing.T) {
+		s := Concat(azor.SliceSeq([]int{1, 2}), azor.SliceSeq([]int{}), azor.SliceSeq([]int{3}))
+		got, err := collect(t, s)
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if !slices.Equal(got, []int{1, 2, 3}) {
+			t.Errorf("got %v, want [1 2 3]", got)
+		}
+	})
+	t.Run("close early", func(t *testing.T) {
+		a := newSource(0, 1, 2)
+		b := newSource(0, 3)
+		got, _ := collect(t, Take(Concat(a.AsyncSeq, b.AsyncSeq), 1))
+		if !slices.Equal(got, []int{1}) {
+			t.Errorf("got %v, want [1]", got)
+		}
+		if !a.closed.Load() || !b.closed.Load() {
+			t.Error("sources not closed")
+		}
+	})
+}
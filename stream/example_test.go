@@ -0,0 +1,31 @@
+package stream_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nalgeon/azor"
+	"github.com/nalgeon/azor/stream"
+)
+
+func Example() {
+	ctx := context.Background()
+	nums := azor.SliceSeq([]int{1, 2, 3, 4, 5, 6, 7, 8})
+
+	// Square the even numbers, 3 at a time,
+	// and sum the first three squares.
+	even := stream.Filter(nums, 1, func(_ context.Context, n int) (bool, error) {
+		return n%2 == 0, nil
+	})
+	squares := stream.Map(even, 3, func(_ context.Context, n int) (int, error) {
+		return n * n, nil
+	})
+	sum := stream.Reduce(ctx, stream.Take(squares, 3), 0, func(acc, n int) (int, error) {
+		return acc + n, nil
+	})
+
+	fmt.Println(sum.Get(ctx))
+
+	// Output:
+	// 56 <nil>
+}
@@ -0,0 +1,81 @@
+// Package stream provides operators for asynchronous sequences
+// ([azor.AsyncSeq]).
+//
+// Operators take one or more source sequences and return a new sequence
+// (Map, Filter, Take, Merge, ...) or a promise of the final result
+// (Reduce, Collect). Sequences are pull-based: an operator pulls values
+// from its sources only when its own consumer pulls a value.
+// Some operators (Map and Filter with concurrency, Merge, Prefetch)
+// pull ahead to keep several values in flight.
+//
+// Every pull respects the context passed to it. Closing a sequence
+// returned by an operator closes its sources, so a consumer that
+// stops early (for example, by breaking out of a range loop over
+// [azor.AsyncSeq.All]) releases the whole pipeline.
+package stream
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nalgeon/azor"
+)
+
+// Pair is a pair of values produced by [Zip].
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// Collect reads all the values from the sequence asynchronously
+// and returns a promise that resolves with them. If reading a value
+// fails, the promise rejects with the error. Closes the sequence
+// when done.
+func Collect[T any](ctx context.Context, src *azor.AsyncSeq[T]) *azor.Promise[[]T] {
+	return Reduce(ctx, src, []T(nil), func(acc []T, val T) ([]T, error) {
+		return append(acc, val), nil
+	})
+}
+
+// Reduce combines all the values from the sequence into a single value,
+// starting with init and calling fn for each value. Returns a promise that
+// resolves with the final value. If reading a value or calling fn fails,
+// the promise rejects with the error. Closes the sequence when done.
+//
+// Panics if the function is nil.
+func Reduce[T, U any](ctx context.Context, src *azor.AsyncSeq[T], init U, fn func(acc U, val T) (U, error)) *azor.Promise[U] {
+	if fn == nil {
+		panic("stream: nil function")
+	}
+	return azor.Run(func() (U, error) {
+		acc := init
+		for val, err := range src.All(ctx) {
+			if err != nil {
+				return init, err
+			}
+			if acc, err = fn(acc, val); err != nil {
+				return init, err
+			}
+		}
+		return acc, nil
+	})
+}
+
+// next pulls the next value from the sequence and waits for it.
+// The pull itself honors the context, so waiting without it
+// doesn't throw away a value the source has already produced.
+func next[T any](ctx context.Context, src *azor.AsyncSeq[T]) (T, error) {
+	return src.Next(ctx).Get(context.Background())
+}
+
+// isEnd reports whether the error marks the end of a sequence.
+func isEnd(err error) bool {
+	return errors.Is(err, azor.ErrEnd)
+}
+
+// closeAll closes all the given sequences.
+func closeAll[T any](srcs []*azor.AsyncSeq[T]) {
+	for _, src := range srcs {
+		src.Close()
+	}
+}
@@ -0,0 +1,140 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nalgeon/azor"
+)
+
+// collect reads all the values from the sequence.
+func collect[T any](t *testing.T, s *azor.AsyncSeq[T]) ([]T, error) {
+	t.Helper()
+	var vals []T
+	for val, err := range s.All(t.Context()) {
+		if err != nil {
+			return vals, err
+		}
+		vals = append(vals, val)
+	}
+	return vals, nil
+}
+
+// source is a test sequence of the given values
+// that records whether it was closed.
+type source[T any] struct {
+	*azor.AsyncSeq[T]
+	closed atomic.Bool
+}
+
+// newSource creates a new source with the given values.
+// The delay is the time it takes to produce each value.
+func newSource[T any](delay time.Duration, vals ...T) *source[T] {
+	src := &source[T]{}
+	i := 0
+	src.AsyncSeq = azor.FuncSeq(func(ctx context.Context) (T, error) {
+		var zero T
+		if i >= len(vals) {
+			return zero, azor.ErrEnd
+		}
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return zero, ctx.Err()
+			}
+		}
+		i++
+		return vals[i-1], nil
+	}, func() { src.closed.Store(true) })
+	return src
+}
+
+// failing returns a sequence that yields the given values
+// and then fails with the error.
+func failing[T any](err error, vals ...T) *azor.AsyncSeq[T] {
+	i := 0
+	return azor.FuncSeq(func(ctx context.Context) (T, error) {
+		if i >= len(vals) {
+			var zero T
+			return zero, err
+		}
+		i++
+		return vals[i-1], nil
+	}, nil)
+}
+
+func TestCollect(t *testing.T) {
+	t.Run("values", func(t *testing.T) {
+		src := newSource(0, 1, 2, 3)
+		got, err := Collect(t.Context(), src.AsyncSeq).Get(t.Context())
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if !slices.Equal(got, []int{1, 2, 3}) {
+			t.Errorf("got %v, want [1 2 3]", got)
+		}
+		if !src.closed.Load() {
+			t.Error("source not closed")
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		_, err := Collect(t.Context(), failing(errFailed, 1, 2)).Get(t.Context())
+		if !errors.Is(err, errFailed) {
+			t.Errorf("got err = %v, want %v", err, errFailed)
+		}
+	})
+}
+
+func TestReduce(t *testing.T) {
+	t.Run("sum", func(t *testing.T) {
+		src := azor.SliceSeq([]int{1, 2, 3, 4})
+		sum := func(acc, val int) (int, error) { return acc + val, nil }
+		got, err := Reduce(t.Context(), src, 10, sum).Get(t.Context())
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if got != 20 {
+			t.Errorf("got %v, want 20", got)
+		}
+	})
+	t.Run("fn error", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		src := newSource(0, 1, 2, 3)
+		fn := func(acc, val int) (int, error) {
+			if val == 2 {
+				return 0, errFailed
+			}
+			return acc + val, nil
+		}
+		_, err := Reduce(t.Context(), src.AsyncSeq, 0, fn).Get(t.Context())
+		if !errors.Is(err, errFailed) {
+			t.Errorf("got err = %v, want %v", err, errFailed)
+		}
+		if !src.closed.Load() {
+			t.Error("source not closed")
+		}
+	})
+	t.Run("canceled", func(t *testing.T) {
+		src := newSource(time.Second, 1, 2, 3)
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+		_, err := Collect(ctx, src.AsyncSeq).Get(t.Context())
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got err = %v, want %v", err, context.DeadlineExceeded)
+		}
+	})
+	t.Run("nil function", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("want panic")
+			}
+		}()
+		Reduce[int, int](t.Context(), azor.SliceSeq([]int{1}), 0, nil)
+	})
+}
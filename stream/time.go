@@ -0,0 +1,114 @@
+package stream
+
+import (
+	"context"
+	"time"
+
+	"github.com/nalgeon/azor"
+)
+
+// Debounce returns a sequence that delivers a value from the source
+// sequence only after the source has been quiet for the given duration.
+// If a new value arrives earlier, the previous one is dropped.
+// When the source ends, the last pending value (if any) is delivered
+// right away.
+func Debounce[T any](src *azor.AsyncSeq[T], d time.Duration) *azor.AsyncSeq[T] {
+	db := &debounce[T]{src: src, d: d}
+	db.ctx, db.cancel = context.WithCancel(context.Background())
+	return azor.FuncSeq(db.next, db.stop)
+}
+
+// debounce delivers the values from the source
+// after a quiet period.
+type debounce[T any] struct {
+	src *azor.AsyncSeq[T]
+	d   time.Duration
+
+	ctx     context.Context // canceled when the sequence is closed
+	cancel  context.CancelFunc
+	pending *azor.Promise[T] // pull from the source in progress
+	last    T                // latest value not delivered yet
+	has     bool             // true if there is a value not delivered yet
+	arrived time.Time        // when the latest value arrived
+	ended   bool             // true if the source has ended
+}
+
+// next returns the next value.
+func (db *debounce[T]) next(ctx context.Context) (T, error) {
+	var zero T
+	if db.ended {
+		return zero, azor.ErrEnd
+	}
+
+	// Watch the quiet timer only when there is a pending value.
+	timer := time.NewTimer(db.d - time.Since(db.arrived))
+	defer timer.Stop()
+	var quiet <-chan time.Time
+	if db.has {
+		quiet = timer.C
+	} else {
+		timer.Stop()
+	}
+
+	for {
+		if db.pending == nil {
+			db.pending = db.src.Next(db.ctx)
+		}
+		select {
+		case <-db.pending.Done():
+			val, err := db.pending.Get(db.ctx)
+			db.pending = nil
+			if err != nil {
+				if isEnd(err) {
+					db.ended = true
+					if db.has {
+						return db.take(), nil
+					}
+				}
+				return zero, err
+			}
+			db.last, db.has = val, true
+			db.arrived = time.Now()
+			timer.Reset(db.d)
+			quiet = timer.C
+		case <-quiet:
+			return db.take(), nil
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// take returns the pending value and clears it.
+func (db *debounce[T]) take() T {
+	var zero T
+	val := db.last
+	db.last, db.has = zero, false
+	return val
+}
+
+// stop cancels the pull in progress and closes the source.
+func (db *debounce[T]) stop() {
+	db.cancel()
+	db.src.Close()
+}
+
+// Throttle returns a sequence that delivers at most one value
+// from the source sequence per interval. It delivers the first value
+// right away, and then drops the values that arrive before the interval
+// since the last delivered value has passed.
+func Throttle[T any](src *azor.AsyncSeq[T], interval time.Duration) *azor.AsyncSeq[T] {
+	var last time.Time
+	return azor.FuncSeq(func(ctx context.Context) (T, error) {
+		for {
+			val, err := next(ctx, src)
+			if err != nil {
+				return val, err
+			}
+			if now := time.Now(); last.IsZero() || now.Sub(last) >= interval {
+				last = now
+				return val, nil
+			}
+		}
+	}, src.Close)
+}
@@ -0,0 +1,71 @@
+package stream
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nalgeon/azor"
+)
+
+// timed returns a sequence that sends each value
+// after the given delay.
+func timed(vals []int, delays []time.Duration) *azor.AsyncSeq[int] {
+	ch := make(chan int)
+	go func() {
+		defer close(ch)
+		for i, val := range vals {
+			time.Sleep(delays[i])
+			ch <- val
+		}
+	}()
+	return azor.ChanSeq(ch)
+}
+
+func TestDebounce(t *testing.T) {
+	t.Run("bursts", func(t *testing.T) {
+		ms := time.Millisecond
+		src := timed(
+			[]int{1, 2, 3, 4, 5},
+			[]time.Duration{0, 5 * ms, 5 * ms, 80 * ms, 5 * ms},
+		)
+		got, err := collect(t, Debounce(src, 40*ms))
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if !slices.Equal(got, []int{3, 5}) {
+			t.Errorf("got %v, want [3 5]", got)
+		}
+	})
+	t.Run("end", func(t *testing.T) {
+		got, _ := collect(t, Debounce(azor.SliceSeq([]int{1, 2, 3}), time.Second))
+		if !slices.Equal(got, []int{3}) {
+			t.Errorf("got %v, want [3]", got)
+		}
+	})
+	t.Run("close", func(t *testing.T) {
+		src := newSource(time.Second, 1)
+		s := Debounce(src.AsyncSeq, time.Millisecond)
+		_ = s.Next(t.Context())
+		time.Sleep(10 * time.Millisecond)
+		s.Close()
+		if !src.closed.Load() {
+			t.Error("source not closed")
+		}
+	})
+}
+
+func TestThrottle(t *testing.T) {
+	ms := time.Millisecond
+	src := timed(
+		[]int{1, 2, 3, 4, 5},
+		[]time.Duration{0, 5 * ms, 5 * ms, 80 * ms, 5 * ms},
+	)
+	got, err := collect(t, Throttle(src, 40*ms))
+	if err != nil {
+		t.Errorf("got err = %v, want nil", err)
+	}
+	if !slices.Equal(got, []int{1, 4}) {
+		t.Errorf("got %v, want [1 4]", got)
+	}
+}
@@ -0,0 +1,396 @@
+package stream
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nalgeon/azor"
+)
+
+// Map returns a sequence of the results of calling fn
+// on each value from the source sequence.
+//
+// If limit is greater than 1, Map pulls values from the source ahead
+// of the consumer and calls fn concurrently, with up to limit calls
+// in flight. The results are still delivered in the source order.
+// Otherwise, Map calls fn sequentially on each pull.
+//
+// If fn fails, the consumer receives the error in place of the value.
+//
+// Panics if the function is nil.
+func Map[T, U any](src *azor.AsyncSeq[T], limit int, fn func(context.Context, T) (U, error)) *azor.AsyncSeq[U] {
+	if fn == nil {
+		panic("stream: nil function")
+	}
+	if limit > 1 {
+		return newAhead(src, limit, fn).seq()
+	}
+	return azor.FuncSeq(func(ctx context.Context) (U, error) {
+		val, err := next(ctx, src)
+		if err != nil {
+			var zero U
+			return zero, err
+		}
+		return fn(ctx, val)
+	}, src.Close)
+}
+
+// Filter returns a sequence of the values from the source sequence
+// for which the predicate returns true.
+//
+// If limit is greater than 1, Filter calls the predicate concurrently,
+// the same way [Map] does. The values are still delivered in the source order.
+//
+// Panics if the predicate is nil.
+func Filter[T any](src *azor.AsyncSeq[T], limit int, pred func(context.Context, T) (bool, error)) *azor.AsyncSeq[T] {
+	if pred == nil {
+		panic("stream: nil function")
+	}
+	checked := Map(src, limit, func(ctx context.Context, val T) (Pair[T, bool], error) {
+		ok, err := pred(ctx, val)
+		return Pair[T, bool]{val, ok}, err
+	})
+	return azor.FuncSeq(func(ctx context.Context) (T, error) {
+		for {
+			p, err := next(ctx, checked)
+			if err != nil || p.Second {
+				return p.First, err
+			}
+		}
+	}, checked.Close)
+}
+
+// FlatMap calls fn on each value from the source sequence
+// and returns a sequence of the values from all the sequences
+// returned by fn, one after another.
+//
+// Panics if the function is nil.
+func FlatMap[T, U any](src *azor.AsyncSeq[T], fn func(context.Context, T) (*azor.AsyncSeq[U], error)) *azor.AsyncSeq[U] {
+	if fn == nil {
+		panic("stream: nil function")
+	}
+	var inner *azor.AsyncSeq[U]
+	return azor.FuncSeq(func(ctx context.Context) (U, error) {
+		var zero U
+		for {
+			if inner == nil {
+				val, err := next(ctx, src)
+				if err != nil {
+					return zero, err
+				}
+				if inner, err = fn(ctx, val); err != nil {
+					return zero, err
+				}
+			}
+			val, err := next(ctx, inner)
+			if isEnd(err) {
+				inner = nil
+				continue
+			}
+			return val, err
+		}
+	}, func() {
+		if inner != nil {
+			inner.Close()
+		}
+		src.Close()
+	})
+}
+
+// Take returns a sequence of the first n values
+// from the source sequence. Closes the source
+// as soon as it receives the n-th value.
+func Take[T any](src *azor.AsyncSeq[T], n int) *azor.AsyncSeq[T] {
+	taken := 0
+	return azor.FuncSeq(func(ctx context.Context) (T, error) {
+		if taken >= n {
+			src.Close()
+			var zero T
+			return zero, azor.ErrEnd
+		}
+		val, err := next(ctx, src)
+		if err != nil {
+			return val, err
+		}
+		taken++
+		if taken == n {
+			src.Close()
+		}
+		return val, nil
+	}, src.Close)
+}
+
+// Skip returns a sequence of the values from the source
+// sequence except the first n.
+func Skip[T any](src *azor.AsyncSeq[T], n int) *azor.AsyncSeq[T] {
+	skipped := 0
+	return azor.FuncSeq(func(ctx context.Context) (T, error) {
+		for skipped < n {
+			if val, err := next(ctx, src); err != nil {
+				return val, err
+			}
+			skipped++
+		}
+		return next(ctx, src)
+	}, src.Close)
+}
+
+// Buffer returns a sequence of batches of values from the source
+// sequence. A batch is complete when it has count values, or when the
+// timeout has passed since the first value in the batch arrived
+// (whichever comes first). If the timeout is zero, batches are
+// completed by count only. When the source ends, the last
+// incomplete batch is delivered as is.
+//
+// Panics if count is less than 1.
+func Buffer[T any](src *azor.AsyncSeq[T], count int, timeout time.Duration) *azor.AsyncSeq[[]T] {
+	if count < 1 {
+		panic("stream: count must be positive")
+	}
+	b := &buffer[T]{src: src, count: count, timeout: timeout}
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+	return azor.FuncSeq(b.next, b.stop)
+}
+
+// buffer collects the values from the source into batches.
+type buffer[T any] struct {
+	src     *azor.AsyncSeq[T]
+	count   int
+	timeout time.Duration
+
+	ctx     context.Context // canceled when the sequence is closed
+	cancel  context.CancelFunc
+	pending *azor.Promise[T] // pull from the source in progress
+	batch   []T              // current batch
+	started time.Time        // when the first value in the batch arrived
+	err     error            // source error to report after the batch
+	ended   bool             // true if the source has ended
+}
+
+// next returns the next batch.
+func (b *buffer[T]) next(ctx context.Context) ([]T, error) {
+	if b.err != nil {
+		err := b.err
+		b.err = nil
+		return nil, err
+	}
+	if b.ended {
+		return nil, azor.ErrEnd
+	}
+
+	var timeout <-chan time.Time
+	if b.timeout > 0 && len(b.batch) > 0 {
+		timer := time.NewTimer(b.timeout - time.Since(b.started))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		// Keep a pull from the source in progress,
+		// so that we can wait for it along with the timeout.
+		if b.pending == nil {
+			b.pending = b.src.Next(b.ctx)
+		}
+		select {
+		case <-b.pending.Done():
+			val, err := b.pending.Get(b.ctx)
+			b.pending = nil
+			if err != nil {
+				if isEnd(err) {
+					b.ended = true
+				} else {
+					b.err = err
+				}
+				if len(b.batch) > 0 {
+					return b.flush(), nil
+				}
+				return b.next(ctx)
+			}
+			b.batch = append(b.batch, val)
+			if len(b.batch) >= b.count {
+				return b.flush(), nil
+			}
+			if len(b.batch) == 1 && b.timeout > 0 {
+				b.started = time.Now()
+				timer := time.NewTimer(b.timeout)
+				defer timer.Stop()
+				timeout = timer.C
+			}
+		case <-timeout:
+			return b.flush(), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// flush returns the current batch and starts a new one.
+func (b *buffer[T]) flush() []T {
+	batch := b.batch
+	b.batch = nil
+	return batch
+}
+
+// stop cancels the pull in progress and closes the source.
+func (b *buffer[T]) stop() {
+	b.cancel()
+	b.src.Close()
+}
+
+// Window returns a sequence of sliding windows over the source sequence.
+// Each window contains the last size values, and a new window is delivered
+// for each new value once there are enough values. For example, windows
+// of size 3 over 1, 2, 3, 4, 5 are [1 2 3], [2 3 4] and [3 4 5].
+// If the source ends before filling the first window, the values
+// are delivered as a single smaller window.
+//
+// Panics if size is less than 1.
+func Window[T any](src *azor.AsyncSeq[T], size int) *azor.AsyncSeq[[]T] {
+	if size < 1 {
+		panic("stream: size must be positive")
+	}
+	var win []T
+	delivered := false
+	return azor.FuncSeq(func(ctx context.Context) ([]T, error) {
+		for {
+			val, err := next(ctx, src)
+			if isEnd(err) && !delivered && len(win) > 0 {
+				delivered = true
+				return slices.Clone(win), nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			win = append(win, val)
+			if len(win) > size {
+				win = win[1:]
+			}
+			if len(win) == size {
+				delivered = true
+				return slices.Clone(win), nil
+			}
+		}
+	}, src.Close)
+}
+
+// Prefetch returns a sequence of the values from the source sequence,
+// pulling up to n values ahead of the consumer in the background.
+// Use it to overlap a slow producer with a slow consumer.
+func Prefetch[T any](src *azor.AsyncSeq[T], n int) *azor.AsyncSeq[T] {
+	return newAhead(src, max(n, 1), func(_ context.Context, val T) (T, error) {
+		return val, nil
+	}).seq()
+}
+
+// ahead pulls values from the source in the background, and calls fn
+// on each value with up to limit calls in flight. Delivers the results
+// in the source order.
+type ahead[T, U any] struct {
+	src   *azor.AsyncSeq[T]
+	limit int
+	fn    func(context.Context, T) (U, error)
+
+	ctx    context.Context // canceled when the sequence is closed
+	cancel context.CancelFunc
+	once   sync.Once             // starts the background pulls
+	out    chan *azor.Promise[U] // results in the source order
+	head   *azor.Promise[U]      // result taken from out but not delivered yet
+}
+
+// newAhead creates a new ahead.
+func newAhead[T, U any](src *azor.AsyncSeq[T], limit int, fn func(context.Context, T) (U, error)) *ahead[T, U] {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ahead[T, U]{
+		src:    src,
+		limit:  limit,
+		fn:     fn,
+		ctx:    ctx,
+		cancel: cancel,
+		out:    make(chan *azor.Promise[U], limit),
+	}
+}
+
+// seq returns a sequence of the results.
+func (a *ahead[T, U]) seq() *azor.AsyncSeq[U] {
+	return azor.FuncSeq(a.next, a.stop)
+}
+
+// next returns the next result.
+func (a *ahead[T, U]) next(ctx context.Context) (U, error) {
+	var zero U
+	a.once.Do(func() { go a.run() })
+
+	if a.head == nil {
+		select {
+		case p, ok := <-a.out:
+			if !ok {
+				return zero, azor.ErrEnd
+			}
+			a.head = p
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+	}
+
+	// Keep the result for the next pull
+	// if this one is canceled.
+	select {
+	case <-a.head.Done():
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+	p := a.head
+	a.head = nil
+	return p.Get(context.Background())
+}
+
+// run pulls the values from the source and starts the calls,
+// until the source ends or fails, or the sequence is closed.
+func (a *ahead[T, U]) run() {
+	defer close(a.out)
+	sem := make(chan struct{}, a.limit)
+	for {
+		val, err := next(a.ctx, a.src)
+		if err != nil {
+			if !isEnd(err) && a.ctx.Err() == nil {
+				a.send(azor.Run(func() (U, error) {
+					var zero U
+					return zero, err
+				}))
+			}
+			return
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-a.ctx.Done():
+			return
+		}
+		p := azor.Run(func() (U, error) {
+			defer func() { <-sem }()
+			return a.fn(a.ctx, val)
+		})
+		if !a.send(p) {
+			return
+		}
+	}
+}
+
+// send queues the result for delivery.
+// Returns false if the sequence is closed.
+func (a *ahead[T, U]) send(p *azor.Promise[U]) bool {
+	select {
+	case a.out <- p:
+		return true
+	case <-a.ctx.Done():
+		return false
+	}
+}
+
+// stop stops the background pulls and closes the source.
+func (a *ahead[T, U]) stop() {
+	a.cancel()
+	a.src.Close()
+}
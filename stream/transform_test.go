@@ -0,0 +1,333 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nalgeon/azor"
+)
+
+func TestMap(t *testing.T) {
+	double := func(_ context.Context, val int) (int, error) { return val * 2, nil }
+
+	t.Run("sequential", func(t *testing.T) {
+		got, err := collect(t, Map(azor.SliceSeq([]int{1, 2, 3}), 1, double))
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if !slices.Equal(got, []int{2, 4, 6}) {
+			t.Errorf("got %v, want [2 4 6]", got)
+		}
+	})
+	t.Run("concurrent", func(t *testing.T) {
+		var running, peak atomic.Int32
+		fn := func(_ context.Context, val int) (int, error) {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			// Later values finish earlier.
+			time.Sleep(time.Duration(10-val) * 2 * time.Millisecond)
+			return val * 2, nil
+		}
+		src := azor.SliceSeq([]int{1, 2, 3, 4, 5, 6})
+		got, err := collect(t, Map(src, 3, fn))
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if !slices.Equal(got, []int{2, 4, 6, 8, 10, 12}) {
+			t.Errorf("got %v, want [2 4 6 8 10 12]", got)
+		}
+		if p := peak.Load(); p < 2 || p > 3 {
+			t.Errorf("got peak concurrency %d, want 2..3", p)
+		}
+	})
+	t.Run("fn error", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		fn := func(_ context.Context, val int) (int, error) {
+			if val == 2 {
+				return 0, errFailed
+			}
+			return val, nil
+		}
+		for _, limit := range []int{1, 4} {
+			got, err := collect(t, Map(azor.SliceSeq([]int{1, 2, 3}), limit, fn))
+			if !errors.Is(err, errFailed) {
+				t.Errorf("limit %d: got err = %v, want %v", limit, err, errFailed)
+			}
+			if !slices.Equal(got, []int{1}) {
+				t.Errorf("limit %d: got %v, want [1]", limit, got)
+			}
+		}
+	})
+	t.Run("source error", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		got, err := collect(t, Map(failing(errFailed, 1, 2), 4, double))
+		if !errors.Is(err, errFailed) {
+			t.Errorf("got err = %v, want %v", err, errFailed)
+		}
+		if !slices.Equal(got, []int{2, 4}) {
+			t.Errorf("got %v, want [2 4]", got)
+		}
+	})
+	t.Run("close early", func(t *testing.T) {
+		src := newSource(0, 1, 2, 3, 4, 5)
+		s := Map(src.AsyncSeq, 2, double)
+		val, err := s.Next(t.Context()).Get(t.Context())
+		if err != nil || val != 2 {
+			t.Errorf("got %v, %v; want 2, nil", val, err)
+		}
+		s.Close()
+		if !src.closed.Load() {
+			t.Error("source not closed")
+		}
+	})
+	t.Run("canceled pull", func(t *testing.T) {
+		fn := func(_ context.Context, val int) (int, error) {
+			time.Sleep(50 * time.Millisecond)
+			return val, nil
+		}
+		s := Map(azor.SliceSeq([]int{1, 2}), 2, fn)
+		defer s.Close()
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+		_, err := s.Next(ctx).Get(t.Context())
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got err = %v, want %v", err, context.DeadlineExceeded)
+		}
+
+		// The value is not lost.
+		val, err := s.Next(t.Context()).Get(t.Context())
+		if err != nil || val != 1 {
+			t.Errorf("got %v, %v; want 1, nil", val, err)
+		}
+	})
+	t.Run("canceled pull sequential", func(t *testing.T) {
+		slow := func(_ context.Context, val int) (int, error) {
+			time.Sleep(30 * time.Millisecond)
+			return val, nil
+		}
+		// The pull is canceled in the middle of the pipeline,
+		// while the inner Map is still working on the value.
+		s := Map(Map(azor.SliceSeq([]int{1, 2, 3}), 1, slow), 1, double)
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+		_, err := s.Next(ctx).Get(t.Context())
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got err = %v, want %v", err, context.DeadlineExceeded)
+		}
+
+		got, err := collect(t, s)
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if !slices.Equal(got, []int{2, 4, 6}) {
+			t.Errorf("got %v, want [2 4 6]", got)
+		}
+	})
+}
+
+func TestFilter(t *testing.T) {
+	even := func(_ context.Context, val int) (bool, error) { return val%2 == 0, nil }
+	for _, limit := range []int{1, 3} {
+		src := azor.SliceSeq([]int{1, 2, 3, 4, 5, 6})
+		got, err := collect(t, Filter(src, limit, even))
+		if err != nil {
+			t.Errorf("limit %d: got err = %v, want nil", limit, err)
+		}
+		if !slices.Equal(got, []int{2, 4, 6}) {
+			t.Errorf("limit %d: got %v, want [2 4 6]", limit, got)
+		}
+	}
+}
+
+func TestFlatMap(t *testing.T) {
+	t.Run("values", func(t *testing.T) {
+		fn := func(_ context.Context, val int) (*azor.AsyncSeq[int], error) {
+			return azor.SliceSeq(slices.Repeat([]int{val}, val)), nil
+		}
+		got, err := collect(t, FlatMap(azor.SliceSeq([]int{1, 0, 2, 3}), fn))
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if !slices.Equal(got, []int{1, 2, 2, 3, 3, 3}) {
+			t.Errorf("got %v, want [1 2 2 3 3 3]", got)
+		}
+	})
+	t.Run("close early", func(t *testing.T) {
+		src := newSource(0, 1, 2)
+		inner := newSource(0, 10, 20)
+		fn := func(_ context.Context, val int) (*azor.AsyncSeq[int], error) {
+			return inner.AsyncSeq, nil
+		}
+		s := FlatMap(src.AsyncSeq, fn)
+		if _, err := s.Next(t.Context()).Get(t.Context()); err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		s.Close()
+		if !src.closed.Load() || !inner.closed.Load() {
+			t.Error("sources not closed")
+		}
+	})
+}
+
+func TestTake(t *testing.T) {
+	t.Run("fewer", func(t *testing.T) {
+		src := newSource(0, 1, 2, 3, 4)
+		got, err := collect(t, Take(src.AsyncSeq, 2))
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if !slices.Equal(got, []int{1, 2}) {
+			t.Errorf("got %v, want [1 2]", got)
+		}
+		if !src.closed.Load() {
+			t.Error("source not closed")
+		}
+	})
+	t.Run("more", func(t *testing.T) {
+		got, _ := collect(t, Take(azor.SliceSeq([]int{1, 2}), 5))
+		if !slices.Equal(got, []int{1, 2}) {
+			t.Errorf("got %v, want [1 2]", got)
+		}
+	})
+	t.Run("closes after n-th", func(t *testing.T) {
+		src := newSource(0, 1, 2, 3)
+		s := Take(src.AsyncSeq, 1)
+		defer s.Close()
+		_, _ = s.Next(t.Context()).Get(t.Context())
+		if !src.closed.Load() {
+			t.Error("source not closed")
+		}
+	})
+}
+
+func TestSkip(t *testing.T) {
+	got, err := collect(t, Skip(azor.SliceSeq([]int{1, 2, 3, 4}), 2))
+	if err != nil {
+		t.Errorf("got err = %v, want nil", err)
+	}
+	if !slices.Equal(got, []int{3, 4}) {
+		t.Errorf("got %v, want [3 4]", got)
+	}
+
+	got, _ = collect(t, Skip(azor.SliceSeq([]int{1, 2}), 5))
+	if len(got) != 0 {
+		t.Errorf("got %v, want empty", got)
+	}
+}
+
+func TestBuffer(t *testing.T) {
+	t.Run("by count", func(t *testing.T) {
+		got, err := collect(t, Buffer(azor.SliceSeq([]int{1, 2, 3, 4, 5}), 2, 0))
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		want := [][]int{{1, 2}, {3, 4}, {5}}
+		if !slices.EqualFunc(got, want, slices.Equal) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+	t.Run("by timeout", func(t *testing.T) {
+		ch := make(chan int)
+		go func() {
+			ch <- 1
+			ch <- 2
+			time.Sleep(50 * time.Millisecond)
+			ch <- 3
+			close(ch)
+		}()
+		got, err := collect(t, Buffer(azor.ChanSeq(ch), 10, 20*time.Millisecond))
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		want := [][]int{{1, 2}, {3}}
+		if !slices.EqualFunc(got, want, slices.Equal) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+	t.Run("error after batch", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		s := Buffer(failing(errFailed, 1, 2, 3), 2, 0)
+		got, err := collect(t, s)
+		if !errors.Is(err, errFailed) {
+			t.Errorf("got err = %v, want %v", err, errFailed)
+		}
+		want := [][]int{{1, 2}, {3}}
+		if !slices.EqualFunc(got, want, slices.Equal) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+	t.Run("close", func(t *testing.T) {
+		src := newSource(time.Second, 1)
+		s := Buffer(src.AsyncSeq, 2, 0)
+		p := s.Next(t.Context())
+		time.Sleep(10 * time.Millisecond)
+		s.Close()
+		if _, err := p.Get(t.Context()); !errors.Is(err, azor.ErrEnd) {
+			t.Errorf("got err = %v, want %v", err, azor.ErrEnd)
+		}
+		if !src.closed.Load() {
+			t.Error("source not closed")
+		}
+	})
+}
+
+func TestWindow(t *testing.T) {
+	t.Run("sliding", func(t *testing.T) {
+		got, err := collect(t, Window(azor.SliceSeq([]int{1, 2, 3, 4, 5}), 3))
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		want := [][]int{{1, 2, 3}, {2, 3, 4}, {3, 4, 5}}
+		if !slices.EqualFunc(got, want, slices.Equal) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+	t.Run("short", func(t *testing.T) {
+		got, _ := collect(t, Window(azor.SliceSeq([]int{1, 2}), 3))
+		want := [][]int{{1, 2}}
+		if !slices.EqualFunc(got, want, slices.Equal) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+}
+
+func TestPrefetch(t *testing.T) {
+	t.Run("values", func(t *testing.T) {
+		got, err := collect(t, Prefetch(azor.SliceSeq([]int{1, 2, 3}), 2))
+		if err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		if !slices.Equal(got, []int{1, 2, 3}) {
+			t.Errorf("got %v, want [1 2 3]", got)
+		}
+	})
+	t.Run("pulls ahead", func(t *testing.T) {
+		var pulled atomic.Int32
+		src := azor.FuncSeq(func(ctx context.Context) (int, error) {
+			return int(pulled.Add(1)), nil
+		}, nil)
+		s := Prefetch(src, 3)
+		defer s.Close()
+
+		if _, err := s.Next(t.Context()).Get(t.Context()); err != nil {
+			t.Errorf("got err = %v, want nil", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+		// One delivered, three buffered, one waiting to be buffered.
+		if n := pulled.Load(); n < 4 || n > 5 {
+			t.Errorf("got %d pulls, want 4..5", n)
+		}
+	})
+}